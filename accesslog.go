@@ -0,0 +1,181 @@
+package logger
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccessLogFormat selects the format of the lines written to Options.AccessLog.
+type AccessLogFormat int
+
+const (
+	// AccessLogCombined writes lines in the Apache Combined Log Format, e.g. `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`.
+	AccessLogCombined AccessLogFormat = iota
+	// AccessLogALB writes lines in the format of AWS Application Load Balancer access logs, with the middleware as the load balancer and its handler as the target, so that logs from both sides of an ALB share one format.
+	AccessLogALB
+)
+
+// Time layouts of the access log formats.
+const (
+	clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+	albTimeFormat = "2006-01-02T15:04:05.000000Z"
+)
+
+// writeAccessLog writes the access log line of r, served by crw from start in duration, to AccessLog.
+func (l *Logger) writeAccessLog(r *http.Request, crw *customResponseWriter, addr string, start time.Time, duration time.Duration) {
+	line := make([]byte, 0, 256)
+	switch l.opt.AccessLogFormat {
+	case AccessLogALB:
+		line = appendALB(line, l, r, crw, addr, start, duration)
+	default:
+		line = appendCombined(line, l, r, crw, addr, start)
+	}
+	line = append(line, '\n')
+
+	l.accessLogMu.Lock()
+	defer l.accessLogMu.Unlock()
+	if _, err := l.opt.AccessLog.Write(line); err != nil {
+		l.opt.Logger.Errorf("logger: failed to write access log: %v", err)
+	}
+}
+
+// appendCombined appends the Combined Log Format line of r to line, without its trailing newline.
+func appendCombined(line []byte, l *Logger, r *http.Request, crw *customResponseWriter, addr string, start time.Time) []byte {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	line = appendCLFField(line, addr)
+	line = append(line, " - "...)
+	user, _, _ := r.BasicAuth()
+	line = appendCLFField(line, user)
+	line = append(line, " ["...)
+	line = start.AppendFormat(line, clfTimeFormat)
+	line = append(line, "] "...)
+	line = appendCLFQuoted(line, r.Method+" "+redactQuery(r.RequestURI, l.redactedParams)+" "+r.Proto)
+	line = append(line, ' ')
+	line = strconv.AppendInt(line, int64(crw.status), 10)
+	line = append(line, ' ')
+	if crw.size > 0 {
+		line = strconv.AppendInt(line, int64(crw.size), 10)
+	} else {
+		line = append(line, '-')
+	}
+	line = append(line, ' ')
+	line = appendCLFQuoted(line, r.Header.Get("Referer"))
+	line = append(line, ' ')
+	return appendCLFQuoted(line, r.Header.Get("User-Agent"))
+}
+
+// appendALB appends the ALB access log line of r to line, without its trailing newline. The request processing time is the time spent in a front proxy queue, from X-Request-Start, the target processing time the time to first byte, and the response processing time the rest of the request.
+func appendALB(line []byte, l *Logger, r *http.Request, crw *customResponseWriter, addr string, start time.Time, duration time.Duration) []byte {
+	scheme := l.scheme(r)
+	switch {
+	case strings.EqualFold(r.Header.Get("Upgrade"), "websocket") && scheme == "https":
+		line = append(line, "wss"...)
+	case strings.EqualFold(r.Header.Get("Upgrade"), "websocket"):
+		line = append(line, "ws"...)
+	case r.ProtoMajor == 2 && scheme == "https":
+		line = append(line, "h2"...)
+	default:
+		line = append(line, scheme...)
+	}
+	line = append(line, ' ')
+	line = start.Add(duration).UTC().AppendFormat(line, albTimeFormat)
+	line = append(line, " - "...)
+	line = appendCLFField(line, addr)
+	line = append(line, " - "...)
+
+	var queued time.Duration
+	if received, ok := parseRequestStart(r.Header.Get(headerRequestStart)); ok && received.Before(start) {
+		queued = start.Sub(received)
+	}
+	for _, d := range []time.Duration{queued, crw.ttfb, duration - crw.ttfb} {
+		if d < 0 {
+			d = 0
+		}
+		line = strconv.AppendFloat(line, d.Seconds(), 'f', 3, 64)
+		line = append(line, ' ')
+	}
+	status := strconv.Itoa(crw.status)
+	line = append(line, status...)
+	line = append(line, ' ')
+	line = append(line, status...)
+	line = append(line, ' ')
+	if r.ContentLength > 0 {
+		line = strconv.AppendInt(line, r.ContentLength, 10)
+	} else {
+		line = append(line, '0')
+	}
+	line = append(line, ' ')
+	line = strconv.AppendInt(line, int64(crw.size), 10)
+	line = append(line, ' ')
+
+	host := r.Host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		if scheme == "https" {
+			host += ":443"
+		} else {
+			host += ":80"
+		}
+	}
+	line = appendCLFQuoted(line, r.Method+" "+scheme+"://"+host+redactQuery(r.RequestURI, l.redactedParams)+" "+r.Proto)
+	line = append(line, ' ')
+	line = appendCLFQuoted(line, r.Header.Get("User-Agent"))
+	line = append(line, ' ')
+	var cipher, protocol, serverName string
+	if r.TLS != nil {
+		cipher = cipherSuiteName(r.TLS.CipherSuite)
+		protocol = strings.Replace(tlsVersionName(r.TLS.Version), "TLS ", "TLSv", 1)
+		serverName = r.TLS.ServerName
+	}
+	line = appendCLFField(line, cipher)
+	line = append(line, ' ')
+	line = appendCLFField(line, protocol)
+	line = append(line, " - "...)
+	line = appendCLFQuoted(line, r.Header.Get("X-Amzn-Trace-Id"))
+	line = append(line, ' ')
+	line = appendCLFQuoted(line, serverName)
+	line = append(line, ` "-" - `...)
+	line = start.UTC().AppendFormat(line, albTimeFormat)
+	line = append(line, ` "forward" "-" "-" "-" `...)
+	line = appendCLFQuoted(line, status)
+	return append(line, ` "-" "-"`...)
+}
+
+// appendCLFField appends the unquoted field s to line, or "-" if s is empty.
+func appendCLFField(line []byte, s string) []byte {
+	if len(s) == 0 {
+		return append(line, '-')
+	}
+	return appendCLFEscaped(line, s, ' ')
+}
+
+// appendCLFQuoted appends the quoted field s to line, or "-" if s is empty.
+func appendCLFQuoted(line []byte, s string) []byte {
+	if len(s) == 0 {
+		return append(line, `"-"`...)
+	}
+	line = append(line, '"')
+	line = appendCLFEscaped(line, s, '"')
+	return append(line, '"')
+}
+
+// appendCLFEscaped appends s to line, escaping backslashes, sep and non-printable characters as Apache does, e.g. "\x0a".
+func appendCLFEscaped(line []byte, s string, sep byte) []byte {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' || c == sep:
+			line = append(line, '\\', c)
+		case c < 0x20 || c >= 0x7f:
+			line = append(line, '\\', 'x', hex[c>>4], hex[c&0xf])
+		default:
+			line = append(line, c)
+		}
+	}
+	return line
+}
@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestAccessLog(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	access := bytes.NewBufferString("")
+
+	l := New(Options{
+		Logger:    logger,
+		AccessLog: access,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo?bar=baz", nil)
+	req.RequestURI = "/foo?bar=baz"
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.SetBasicAuth("frank", "secret")
+	req.Header.Set("User-Agent", `curl/7.64.1 "quoted"`)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_status=200")
+	expectContainsTrue(t, access.String(), `10.0.0.1 - frank [`)
+	expectContainsTrue(t, access.String(), `] "GET /foo?bar=baz HTTP/1.1" 200 3 "-" "curl/7.64.1 \"quoted\""`+"\n")
+}
+
+func TestAccessLogOnly(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	access := bytes.NewBufferString("")
+
+	l := New(Options{
+		Logger:        logger,
+		AccessLog:     access,
+		AccessLogOnly: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("HEAD", "/foo", nil)
+	req.RequestURI = "/foo"
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})).ServeHTTP(res, req)
+
+	expect(t, buf.String(), "")
+	expectContainsTrue(t, access.String(), `"HEAD /foo HTTP/1.1" 204 - "-" "-"`)
+}
+
+func TestAppendCombined(t *testing.T) {
+	l := New()
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RequestURI = "/\n"
+	start := time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60))
+	line := appendCombined(nil, l, req, &customResponseWriter{status: 200, size: 2326}, "127.0.0.1", start)
+	expect(t, string(line), `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /\x0a HTTP/1.1" 200 2326 "-" "-"`)
+}
+
+func TestAppendALB(t *testing.T) {
+	l := New()
+	req, _ := http.NewRequest("GET", "http://example.com/foo", nil)
+	req.RequestURI = "/foo?bar=baz"
+	req.Header.Set("User-Agent", "curl/7.64.1")
+	req.Header.Set("X-Amzn-Trace-Id", "Root=1-58337262-36d228ad5d99923122bbe354")
+	start := time.Date(2018, 7, 2, 22, 23, 0, 0, time.UTC)
+	req.Header.Set("X-Request-Start", "t=1530570179.998")
+	crw := &customResponseWriter{status: 200, size: 34, ttfb: 1500 * time.Microsecond}
+	line := appendALB(nil, l, req, crw, "192.168.131.39:2817", start, 2*time.Millisecond)
+	expect(t, string(line), `http 2018-07-02T22:23:00.002000Z - 192.168.131.39:2817 - 0.002 0.002 0.001 200 200 0 34 "GET http://example.com:80/foo?bar=baz HTTP/1.1" "curl/7.64.1" - - - "Root=1-58337262-36d228ad5d99923122bbe354" "-" "-" - 2018-07-02T22:23:00.000000Z "forward" "-" "-" "-" "200" "-" "-"`)
+}
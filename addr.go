@@ -0,0 +1,219 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// remoteAddr returns the client address to log for r, and the port to log, if any.
+func (l *Logger) remoteAddr(r *http.Request) (addr, port string) {
+	addr = l.clientAddr(r)
+	if l.opt.LogPort && addr == r.RemoteAddr {
+		_, port, _ = net.SplitHostPort(addr)
+	}
+	return l.transformAddr(addr), port
+}
+
+// clientAddr returns the address of the client of r, as found in RemoteAddressHeaders when they can be trusted, or r.RemoteAddr.
+func (l *Logger) clientAddr(r *http.Request) string {
+	if !l.trustedPeer(r) {
+		return r.RemoteAddr
+	}
+	for _, headerKey := range l.opt.RemoteAddressHeaders {
+		name := http.CanonicalHeaderKey(headerKey)
+		if val := l.forwardedAddr(headerValue(r.Header, name)); len(val) > 0 {
+			return l.redactor.redact(name, val)
+		}
+	}
+	return r.RemoteAddr
+}
+
+// ForwardedStrategy selects which address of a comma separated forwarding chain, such as `X-Forwarded-For: client, proxy1, proxy2`, is logged.
+type ForwardedStrategy int
+
+const (
+	// ForwardedRaw logs the header value as is.
+	ForwardedRaw ForwardedStrategy = iota
+	// ForwardedLeftmost logs the first address of the chain, i.e. the address claimed by the client, which can be spoofed.
+	ForwardedLeftmost
+	// ForwardedRightmostUntrusted logs the last address of the chain that is not within TrustedProxies, i.e. the address the first trusted proxy received the request from. It is the first address when all of them are trusted.
+	ForwardedRightmostUntrusted
+)
+
+// forwardedAddr returns the address to log out of the forwarding chain val, according to the ForwardedStrategy.
+func (l *Logger) forwardedAddr(val string) string {
+	if l.opt.ForwardedStrategy == ForwardedRaw || strings.IndexByte(val, ',') < 0 {
+		return strings.TrimSpace(val)
+	}
+
+	var hops []string
+	for _, hop := range strings.Split(val, ",") {
+		if hop = strings.TrimSpace(hop); len(hop) > 0 {
+			hops = append(hops, hop)
+		}
+	}
+	if len(hops) == 0 {
+		return ""
+	}
+
+	if l.opt.ForwardedStrategy == ForwardedRightmostUntrusted {
+		for i := len(hops) - 1; i >= 0; i-- {
+			if !containsIP(l.opt.TrustedProxies, parseAddrIP(hops[i])) {
+				return hops[i]
+			}
+		}
+	}
+	return hops[0]
+}
+
+// transformAddr applies the normalization, anonymization and transformation options to addr.
+func (l *Logger) transformAddr(addr string) string {
+	if l.opt.NormalizeAddr {
+		addr = normalizeAddr(addr)
+	}
+	if l.opt.AnonymizeAddr {
+		addr = AnonymizeIP(addr)
+	}
+	if l.opt.AddrTransform != nil {
+		addr = l.opt.AddrTransform(addr)
+	}
+	return addr
+}
+
+// normalizeAddr strips the port and brackets of addr, and returns the canonical form of its IP address. Anything that is not an IP address is returned unchanged.
+func normalizeAddr(addr string) string {
+	if ip := parseAddrIP(addr); ip != nil {
+		return ip.String()
+	}
+	return addr
+}
+
+// AnonymizeIP zeroes the last octet of an IPv4 address, or the last 80 bits of an IPv6 address. addr may carry a port, which is kept. addr may also be a comma separated forwarding chain, as logged under ForwardedRaw, whose hops are anonymized each. Anything that is not an IP address is returned unchanged.
+func AnonymizeIP(addr string) string {
+	if strings.IndexByte(addr, ',') >= 0 {
+		hops := strings.Split(addr, ",")
+		for i, hop := range hops {
+			hops[i] = AnonymizeIP(strings.TrimSpace(hop))
+		}
+		return strings.Join(hops, ", ")
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, ""
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return addr
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4.Mask(net.CIDRMask(24, 32))
+	} else {
+		ip = ip.Mask(net.CIDRMask(48, 128))
+	}
+
+	if len(port) > 0 {
+		return net.JoinHostPort(ip.String(), port)
+	}
+	return ip.String()
+}
+
+// HashIP returns an Options.AddrTransform replacing addresses with the first 16 hex digits of their HMAC-SHA256 keyed by salt, so that a client remains correlatable across requests without its address being recoverable. The port, if any, is dropped before hashing.
+func HashIP(salt string) func(addr string) string {
+	key := []byte(salt)
+	return func(addr string) string {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(addr))
+		return hex.EncodeToString(mac.Sum(nil))[:16]
+	}
+}
+
+// scheme returns the scheme of r, as found in the SchemeHeader when it can be trusted, or derived from r.TLS.
+func (l *Logger) scheme(r *http.Request) string {
+	if len(l.opt.SchemeHeader) > 0 && l.trustedPeer(r) {
+		val := r.Header.Get(l.opt.SchemeHeader)
+		if i := strings.IndexByte(val, ','); i >= 0 {
+			val = val[:i]
+		}
+		if val = strings.ToLower(strings.TrimSpace(val)); len(val) > 0 {
+			return val
+		}
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Values of the `traffic_origin` field.
+const (
+	OriginInternal = "internal"
+	OriginExternal = "external"
+)
+
+// trafficOrigin classifies r as internal or external traffic, and reports whether classification is configured at all.
+func (l *Logger) trafficOrigin(r *http.Request) (string, bool) {
+	if len(l.opt.InternalNetworks) == 0 && !l.opt.InternalClientCerts && len(l.opt.ExternalHeader) == 0 {
+		return "", false
+	}
+
+	switch {
+	case len(l.opt.ExternalHeader) > 0 && len(r.Header.Get(l.opt.ExternalHeader)) > 0:
+		return OriginExternal, true
+	case l.opt.InternalClientCerts && r.TLS != nil && len(r.TLS.VerifiedChains) > 0:
+		return OriginInternal, true
+	case containsIP(l.opt.InternalNetworks, parseAddrIP(l.clientAddr(r))):
+		return OriginInternal, true
+	}
+	return OriginExternal, true
+}
+
+// trustedPeer reports whether the headers set by proxies, such as RemoteAddressHeaders or SchemeHeader, can be trusted for r, i.e. whether r.RemoteAddr is within TrustedProxies. Every peer is trusted when TrustedProxies is empty.
+func (l *Logger) trustedPeer(r *http.Request) bool {
+	if len(l.opt.TrustedProxies) == 0 {
+		return true
+	}
+	return containsIP(l.opt.TrustedProxies, parseAddrIP(r.RemoteAddr))
+}
+
+// parseAddrIP parses the IP address of addr, which may carry a port or be bracketed. It returns nil when addr is not an IP address.
+func parseAddrIP(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"))
+}
+
+// containsIP reports whether ip is within any of networks.
+func containsIP(networks []net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCIDRs parses a list of CIDR notation networks, such as "10.0.0.0/8" or "fd00::/8", for use as Options.TrustedProxies.
+func ParseCIDRs(cidrs ...string) ([]net.IPNet, error) {
+	networks := make([]net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, *network)
+	}
+	return networks, nil
+}
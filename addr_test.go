@@ -0,0 +1,318 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestAnonymizeIP(t *testing.T) {
+	for addr, anonymized := range map[string]string{
+		"8.8.4.4":                              "8.8.4.0",
+		"8.8.4.4:1234":                         "8.8.4.0:1234",
+		"2001:db8:85a3:8d3:1319:8a2e:370:7348": "2001:db8:85a3::",
+		"[2001:db8:85a3:8d3:1319:8a2e:370:7348]:443": "[2001:db8:85a3::]:443",
+		"::ffff:8.8.4.4":                     "8.8.4.0",
+		"203.0.113.77, 198.51.100.9,unknown": "203.0.113.0, 198.51.100.0, unknown",
+		"not-an-ip":                          "not-an-ip",
+		"":                                   "",
+	} {
+		expect(t, AnonymizeIP(addr), anonymized)
+	}
+}
+
+func TestAnonymizeAddr(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:               logger,
+		RemoteAddressHeaders: []string{"X-Real-IP", "X-Forwarded-For"},
+		AnonymizeAddr:        true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "8.8.4.4:1234"
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "http_addr=\"8.8.4.0:1234\"")
+
+	buf.Reset()
+	req.Header.Set("X-Real-IP", "98.76.54.32")
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "http_addr=98.76.54.0")
+
+	// Every hop of a forwarding chain logged under ForwardedRaw is anonymized.
+	buf.Reset()
+	req.Header.Del("X-Real-IP")
+	req.Header.Set("X-Forwarded-For", "203.0.113.77, 198.51.100.9")
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "http_addr=\"203.0.113.0, 198.51.100.0\"")
+	expectContainsFalse(t, buf.String(), "203.0.113.77")
+	expectContainsFalse(t, buf.String(), "198.51.100.9")
+}
+
+func TestHashIP(t *testing.T) {
+	hash := HashIP("salt")
+
+	expect(t, len(hash("8.8.4.4")), 16)
+	expect(t, hash("8.8.4.4"), hash("8.8.4.4:1234"))
+	if hash("8.8.4.4") == hash("8.8.4.5") {
+		t.Errorf("Expected distinct addresses to hash differently")
+	}
+	if hash("8.8.4.4") == HashIP("pepper")("8.8.4.4") {
+		t.Errorf("Expected distinct salts to hash differently")
+	}
+}
+
+func TestAddrTransform(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	var transformed string
+	l := New(Options{
+		Logger:        logger,
+		AnonymizeAddr: true,
+		AddrTransform: func(addr string) string {
+			transformed = addr
+			return "client"
+		},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "8.8.4.4"
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expect(t, transformed, "8.8.4.0")
+	expectContainsTrue(t, buf.String(), "http_addr=client")
+}
+
+func TestParseCIDRs(t *testing.T) {
+	networks, err := ParseCIDRs("10.0.0.0/8", "fd00::/8")
+	expect(t, err, nil)
+	expect(t, len(networks), 2)
+	expect(t, containsIP(networks, parseAddrIP("10.1.2.3:80")), true)
+	expect(t, containsIP(networks, parseAddrIP("[fd00::1]:80")), true)
+	expect(t, containsIP(networks, parseAddrIP("8.8.4.4")), false)
+	expect(t, containsIP(networks, parseAddrIP("not-an-ip")), false)
+
+	_, err = ParseCIDRs("10.0.0.0")
+	if err == nil {
+		t.Errorf("Expected an error")
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	trusted, _ := ParseCIDRs("10.0.0.0/8")
+	l := New(Options{
+		Logger:               logger,
+		RemoteAddressHeaders: []string{"X-Real-IP"},
+		TrustedProxies:       trusted,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Real-IP", "98.76.54.32")
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "http_addr=98.76.54.32")
+
+	buf.Reset()
+	req.RemoteAddr = "8.8.4.4:1234"
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "http_addr=\"8.8.4.4:1234\"")
+	expectContainsFalse(t, buf.String(), "98.76.54.32")
+}
+
+func TestForwardedStrategy(t *testing.T) {
+	trusted, _ := ParseCIDRs("10.0.0.0/8")
+	chain := "1.1.1.1, 2.2.2.2,10.0.0.2 , 10.0.0.1"
+
+	for strategy, addr := range map[ForwardedStrategy]string{
+		ForwardedRaw:                chain,
+		ForwardedLeftmost:           "1.1.1.1",
+		ForwardedRightmostUntrusted: "2.2.2.2",
+	} {
+		l := New(Options{
+			Logger:            logrus.New(),
+			ForwardedStrategy: strategy,
+			TrustedProxies:    trusted,
+		})
+		expect(t, l.forwardedAddr(chain), addr)
+		expect(t, l.forwardedAddr(" 3.3.3.3 "), "3.3.3.3")
+	}
+
+	l := New(Options{ForwardedStrategy: ForwardedRightmostUntrusted, TrustedProxies: trusted})
+	expect(t, l.forwardedAddr("10.0.0.3, 10.0.0.2"), "10.0.0.3")
+	expect(t, l.forwardedAddr("1.1.1.1, [2001:db8::1]"), "[2001:db8::1]")
+	expect(t, l.forwardedAddr(" , "), "")
+
+	l = New(Options{ForwardedStrategy: ForwardedRightmostUntrusted})
+	expect(t, l.forwardedAddr("1.1.1.1, 2.2.2.2"), "2.2.2.2")
+}
+
+func TestForwardedForMultipleHeaders(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	trusted, _ := ParseCIDRs("10.0.0.0/8")
+	l := New(Options{
+		Logger:               logger,
+		RemoteAddressHeaders: []string{"X-Forwarded-For"},
+		ForwardedStrategy:    ForwardedRightmostUntrusted,
+		TrustedProxies:       trusted,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Add("X-Forwarded-For", "1.1.1.1, 2.2.2.2")
+	req.Header.Add("X-Forwarded-For", "10.0.0.2")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_addr=2.2.2.2")
+}
+
+func TestNormalizeAddr(t *testing.T) {
+	for addr, normalized := range map[string]string{
+		"8.8.4.4":         "8.8.4.4",
+		"8.8.4.4:1234":    "8.8.4.4",
+		"[::1]:1234":      "::1",
+		"[2001:DB8:0::1]": "2001:db8::1",
+		"::ffff:8.8.4.4":  "8.8.4.4",
+		"not-an-ip":       "not-an-ip",
+		"":                "",
+	} {
+		expect(t, normalizeAddr(addr), normalized)
+	}
+}
+
+func TestNormalizeAddrWithPort(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:               logger,
+		RemoteAddressHeaders: []string{"X-Real-IP"},
+		NormalizeAddr:        true,
+		LogPort:              true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "[::1]:1234"
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "http_addr=\"::1\"")
+	expectContainsTrue(t, buf.String(), "http_port=1234")
+
+	// The port of the proxy is not the port of the client.
+	buf.Reset()
+	req.Header.Set("X-Real-IP", "98.76.54.32")
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "http_addr=98.76.54.32")
+	expectContainsFalse(t, buf.String(), "http_port")
+}
+
+func TestHostAndScheme(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://example.com/foo", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "http_host=example.com")
+	expectContainsTrue(t, buf.String(), "http_scheme=http ")
+
+	buf.Reset()
+	req.TLS = &tls.ConnectionState{}
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "http_scheme=https")
+}
+
+func TestSchemeHeader(t *testing.T) {
+	trusted, _ := ParseCIDRs("10.0.0.0/8")
+	l := New(Options{
+		Logger:         logrus.New(),
+		SchemeHeader:   "X-Forwarded-Proto",
+		TrustedProxies: trusted,
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	expect(t, l.scheme(req), "http")
+
+	req.Header.Set("X-Forwarded-Proto", "HTTPS, http")
+	expect(t, l.scheme(req), "https")
+
+	req.RemoteAddr = "8.8.4.4:1234"
+	expect(t, l.scheme(req), "http")
+}
+
+func TestTrafficOrigin(t *testing.T) {
+	internal, _ := ParseCIDRs("10.0.0.0/8")
+	l := New(Options{
+		Logger:              logrus.New(),
+		InternalNetworks:    internal,
+		InternalClientCerts: true,
+		ExternalHeader:      "X-Edge",
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	origin, ok := l.trafficOrigin(req)
+	expect(t, ok, true)
+	expect(t, origin, OriginInternal)
+
+	req.RemoteAddr = "8.8.4.4:1234"
+	origin, _ = l.trafficOrigin(req)
+	expect(t, origin, OriginExternal)
+
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{}}}
+	origin, _ = l.trafficOrigin(req)
+	expect(t, origin, OriginInternal)
+
+	req.Header.Set("X-Edge", "1")
+	origin, _ = l.trafficOrigin(req)
+	expect(t, origin, OriginExternal)
+
+	_, ok = New().trafficOrigin(req)
+	expect(t, ok, false)
+}
+
+func TestTrafficOriginField(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	internal, _ := ParseCIDRs("10.0.0.0/8")
+	l := New(Options{
+		Logger:           logger,
+		InternalNetworks: internal,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "traffic_origin=internal")
+}
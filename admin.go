@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// adminState is the state exposed and changed by AdminHandler.
+type adminState struct {
+	Level              string   `json:"level"`
+	ErrorsOnly         bool     `json:"errors_only"`
+	IgnoredRequestURIs []string `json:"ignored_request_uris"`
+}
+
+// adminUpdate is a change requested from AdminHandler. Unset fields are left unchanged.
+type adminUpdate struct {
+	Level      *string  `json:"level"`
+	ErrorsOnly *bool    `json:"errors_only"`
+	Ignore     []string `json:"ignore"`
+	Unignore   []string `json:"unignore"`
+}
+
+// AdminHandler returns a handler letting operators inspect and change, at runtime, the level of the output logrus Logger, ErrorsOnly and IgnoredRequestURIs. A GET returns them as JSON:
+//
+//	{"level":"info","errors_only":false,"ignored_request_uris":["/favicon.ico"]}
+//
+// A PUT or POST changes the ones given in its JSON body, adding the URIs of "ignore" to IgnoredRequestURIs and removing the ones of "unignore", and returns the result:
+//
+//	{"level":"debug","errors_only":true,"ignore":["/metrics"],"unignore":["/favicon.ico"]}
+//
+// The handler does no authentication of its own, so it should only be reachable by operators.
+func (l *Logger) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET", "HEAD":
+		case "PUT", "POST":
+			var update adminUpdate
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := l.adminUpdate(update); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		o := l.Options()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(adminState{
+			Level:              o.Logger.GetLevel().String(),
+			ErrorsOnly:         o.ErrorsOnly,
+			IgnoredRequestURIs: o.IgnoredRequestURIs,
+		})
+	})
+}
+
+// adminUpdate applies update, or returns an error without applying any of it.
+func (l *Logger) adminUpdate(update adminUpdate) error {
+	var level logrus.Level
+	if update.Level != nil {
+		var err error
+		if level, err = logrus.ParseLevel(*update.Level); err != nil {
+			return err
+		}
+	}
+
+	l.UpdateOptions(func(o *Options) {
+		if update.Level != nil {
+			o.Logger.SetLevel(level)
+		}
+		if update.ErrorsOnly != nil {
+			o.ErrorsOnly = *update.ErrorsOnly
+		}
+		if len(update.Ignore) > 0 || len(update.Unignore) > 0 {
+			o.IgnoredRequestURIs = updateURIs(o.IgnoredRequestURIs, update.Ignore, update.Unignore)
+		}
+	})
+	return nil
+}
+
+// updateURIs returns a copy of uris with add appended, unless already present, and remove removed.
+func updateURIs(uris, add, remove []string) []string {
+	removed := make(map[string]bool, len(remove))
+	for _, uri := range remove {
+		removed[uri] = true
+	}
+
+	updated := make([]string, 0, len(uris)+len(add))
+	seen := make(map[string]bool, len(uris)+len(add))
+	for _, list := range [][]string{uris, add} {
+		for _, uri := range list {
+			if !removed[uri] && !seen[uri] {
+				seen[uri] = true
+				updated = append(updated, uri)
+			}
+		}
+	}
+	return updated
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestUpdateURIs(t *testing.T) {
+	uris := []string{"/a", "/b"}
+	updated := updateURIs(uris, []string{"/c", "/a"}, []string{"/b"})
+
+	expect(t, strings.Join(updated, ","), "/a,/c")
+	expect(t, strings.Join(uris, ","), "/a,/b")
+}
+
+func TestAdminHandler(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:             logger,
+		IgnoredRequestURIs: []string{"/favicon.ico"},
+	})
+	admin := l.AdminHandler()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/logging", nil)
+	admin.ServeHTTP(res, req)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), `{"level":"info","errors_only":false,"ignored_request_uris":["/favicon.ico"]}`+"\n")
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/admin/logging", strings.NewReader(`{"level":"debug","errors_only":true,"ignore":["/metrics"],"unignore":["/favicon.ico"]}`))
+	admin.ServeHTTP(res, req)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), `{"level":"debug","errors_only":true,"ignored_request_uris":["/metrics"]}`+"\n")
+	expect(t, logger.GetLevel(), logrus.DebugLevel)
+
+	// Errors only.
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expect(t, buf.String(), "")
+	l.Handler(myHandlerWithError).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "http_status=502")
+
+	for method, body := range map[string]string{
+		"PUT":    `{"level":"loud","errors_only":false}`,
+		"POST":   `not json`,
+		"DELETE": ``,
+	} {
+		res = httptest.NewRecorder()
+		req, _ = http.NewRequest(method, "/admin/logging", strings.NewReader(body))
+		admin.ServeHTTP(res, req)
+		if res.Code != http.StatusBadRequest && res.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected %s %s to fail - Got [%v]", method, body, res.Code)
+		}
+	}
+	// Invalid updates are not applied at all.
+	expect(t, l.Options().ErrorsOnly, true)
+}
@@ -0,0 +1,24 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// apiKeyFingerprintSize is the number of bytes of the digest of an API key kept in its fingerprint.
+const apiKeyFingerprintSize = 8
+
+// apiKeyFingerprint returns the hex encoded fingerprint of key: the start of its HMAC-SHA256 with secret, or of its SHA256 when secret is empty.
+func apiKeyFingerprint(key string, secret []byte) string {
+	var sum []byte
+	if len(secret) > 0 {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(key))
+		sum = mac.Sum(nil)
+	} else {
+		digest := sha256.Sum256([]byte(key))
+		sum = digest[:]
+	}
+	return hex.EncodeToString(sum[:apiKeyFingerprintSize])
+}
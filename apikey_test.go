@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestAPIKeyFingerprint(t *testing.T) {
+	expect(t, apiKeyFingerprint("abc", nil), "ba7816bf8f01cfea")
+	expect(t, len(apiKeyFingerprint("abc", []byte("secret"))), 16)
+	expect(t, apiKeyFingerprint("abc", []byte("secret")) == apiKeyFingerprint("abc", nil), false)
+	expect(t, apiKeyFingerprint("abc", []byte("secret")), apiKeyFingerprint("abc", []byte("secret")))
+}
+
+func TestAPIKeyHeader(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:         logger,
+		APIKeyHeader:   "X-Client-Key",
+		RequestHeaders: []string{"X-Client-Key"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Client-Key", "abc")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_api_key_fingerprint=ba7816bf8f01cfea")
+	expectContainsTrue(t, buf.String(), "http_req_x_client_key=REDACTED")
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as an HTTP date, into the number of seconds the client was told to wait.
+func parseRetryAfter(header string, now time.Time) (int, bool) {
+	header = strings.TrimSpace(header)
+	if len(header) == 0 {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return secs, true
+	}
+	date, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+	secs := int(date.Sub(now) / time.Second)
+	if secs < 0 {
+		secs = 0
+	}
+	return secs, true
+}
+
+// arrivalRates estimates the arrival rate of requests per key, over a sliding window.
+type arrivalRates struct {
+	mu      sync.Mutex
+	window  time.Duration
+	windows *lruCache
+}
+
+// arrivalWindow counts the arrivals of the current and previous windows of a key.
+type arrivalWindow struct {
+	start    time.Time
+	count    int
+	previous int
+}
+
+func newArrivalRates(window time.Duration, size int) *arrivalRates {
+	return &arrivalRates{
+		window:  window,
+		windows: newLRUCache(size),
+	}
+}
+
+// observe records an arrival for key at now, and returns the estimated arrival rate for key, in requests per second.
+func (a *arrivalRates) observe(key string, now time.Time) float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var w *arrivalWindow
+	if v, ok := a.windows.get(key); ok {
+		w = v.(*arrivalWindow)
+	} else {
+		w = &arrivalWindow{start: now}
+		a.windows.add(key, w)
+	}
+
+	if elapsed := now.Sub(w.start); elapsed >= 2*a.window {
+		w.start, w.count, w.previous = now, 0, 0
+	} else if elapsed >= a.window {
+		w.start, w.count, w.previous = w.start.Add(a.window), 0, w.count
+	}
+	w.count++
+
+	// Weigh the previous window by how much of it still overlaps the sliding window.
+	overlap := 1 - float64(now.Sub(w.start))/float64(a.window)
+	return (float64(w.previous)*overlap + float64(w.count)) / a.window.Seconds()
+}
@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+
+	secs, ok := parseRetryAfter("120", now)
+	expect(t, ok, true)
+	expect(t, secs, 120)
+
+	secs, ok = parseRetryAfter("Wed, 21 Oct 2015 07:29:00 GMT", now)
+	expect(t, ok, true)
+	expect(t, secs, 60)
+
+	secs, ok = parseRetryAfter("Wed, 21 Oct 2015 07:27:00 GMT", now)
+	expect(t, ok, true)
+	expect(t, secs, 0)
+
+	for _, header := range []string{"", "-1", "soon"} {
+		if _, ok := parseRetryAfter(header, now); ok {
+			t.Errorf("Expected [%s] to be invalid", header)
+		}
+	}
+}
+
+func TestArrivalRates(t *testing.T) {
+	rates := newArrivalRates(time.Second, 10)
+	start := time.Now()
+
+	var rate float64
+	for i := 0; i < 10; i++ {
+		rate = rates.observe("a", start.Add(time.Duration(i)*50*time.Millisecond))
+	}
+	expect(t, rate, 10.0)
+	expect(t, rates.observe("b", start), 1.0)
+
+	// Half way through the next window, half of the previous one still counts.
+	expect(t, rates.observe("a", start.Add(1500*time.Millisecond)), 6.0)
+
+	// Long idle keys start over.
+	expect(t, rates.observe("a", start.Add(time.Hour)), 1.0)
+}
+
+func TestRetryAfter(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:            logger,
+		ArrivalRateWindow: time.Minute,
+	})
+
+	tooMany := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	for i := 0; i < 3; i++ {
+		buf.Reset()
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		req.RemoteAddr = "8.8.4.4"
+		l.Handler(tooMany).ServeHTTP(res, req)
+	}
+
+	expectContainsTrue(t, buf.String(), "http_retry_after=30")
+	expectContainsTrue(t, buf.String(), "http_arrival_rate=0.05")
+}
+
+func TestRetryAfterWithoutArrivalRate(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_retry_after=30")
+	expectContainsFalse(t, buf.String(), "http_arrival_rate")
+}
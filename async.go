@@ -0,0 +1,221 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AsyncQueueFull selects what happens to entries when the AsyncQueueSize queue is full.
+type AsyncQueueFull int
+
+const (
+	// AsyncBlock delays the response until there is room in the queue, so that no entry is lost.
+	AsyncBlock AsyncQueueFull = iota
+	// AsyncDrop drops the entry, so that responses are never delayed, and counts it in Dropped.
+	AsyncDrop
+)
+
+// logEntry is a request entry, as it is handed over to be written.
+type logEntry struct {
+	logger *logrus.Logger
+	// tee are the other loggers the entry is written to.
+	tee     []*logrus.Logger
+	time    time.Time
+	level   logrus.Level
+	message string
+	fields  logrus.Fields
+	// deadline is when the entry expires if still queued, under AsyncEntryTimeout.
+	deadline time.Time
+	// sync is set for entries written synchronously under AsyncSync.
+	sync bool
+	// pooled is set for fields from newFields, which are released once written. Fields OnLog replaced them with belong to the caller.
+	pooled bool
+}
+
+// asyncQueue holds the entries waiting to be written by the AsyncWorkers.
+type asyncQueue struct {
+	// dropped counts the entries dropped under AsyncDrop, expired those dropped under AsyncEntryTimeout. They come first for 64-bit alignment of atomic operations.
+	dropped uint64
+	expired uint64
+	timeout time.Duration
+	entries chan logEntry
+	workers sync.WaitGroup
+	// mu guards closed. Entries are queued under a read lock, so that entries is never closed under a sender.
+	mu     sync.RWMutex
+	closed bool
+	// pending counts the entries queued but not yet written, idle is signaled when it drops to 0.
+	pendingMu sync.Mutex
+	pending   int
+	idle      *sync.Cond
+}
+
+func newAsyncQueue(size int, timeout time.Duration) *asyncQueue {
+	q := &asyncQueue{entries: make(chan logEntry, size), timeout: timeout}
+	q.idle = sync.NewCond(&q.pendingMu)
+	return q
+}
+
+func (q *asyncQueue) addPending(delta int) {
+	q.pendingMu.Lock()
+	defer q.pendingMu.Unlock()
+
+	q.pending += delta
+	if q.pending == 0 {
+		q.idle.Broadcast()
+	}
+}
+
+// asyncSync reports whether the entry of r completed with status is written synchronously at level, under AsyncSync.
+func (l *Logger) asyncSync(r *http.Request, status int, level logrus.Level) bool {
+	return l.async != nil && l.opt.AsyncSync != nil && l.opt.AsyncSync(r, status, level)
+}
+
+// AsyncSyncCritical is an Options.AsyncSync writing the entries of 5xx responses, and those at Error level or more severe ones, such as the entries of panicking handlers, synchronously.
+func AsyncSyncCritical(r *http.Request, status int, level logrus.Level) bool {
+	return status >= 500 || level <= logrus.ErrorLevel
+}
+
+// emit writes e, or queues it when AsyncQueueSize is set, e is not to be written synchronously, and the Logger is not closed.
+func (l *Logger) emit(e logEntry) {
+	if q := l.async; q != nil && !e.sync {
+		q.mu.RLock()
+		defer q.mu.RUnlock()
+
+		if !q.closed {
+			if q.timeout > 0 {
+				e.deadline = time.Now().Add(q.timeout)
+			}
+			q.addPending(1)
+			if l.opt.AsyncQueueFull == AsyncDrop {
+				select {
+				case q.entries <- e:
+				default:
+					q.addPending(-1)
+					atomic.AddUint64(&q.dropped, 1)
+					e.release()
+				}
+				return
+			}
+			q.entries <- e
+			return
+		}
+	}
+	e.write()
+}
+
+// Dropped returns the number of entries dropped so far because the AsyncQueueSize queue was full, under AsyncDrop.
+func (l *Logger) Dropped() uint64 {
+	if l.async == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&l.async.dropped)
+}
+
+// Expired returns the number of entries dropped so far because they were queued for longer than AsyncEntryTimeout.
+func (l *Logger) Expired() uint64 {
+	if l.async == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&l.async.expired)
+}
+
+// Flush waits until the entries queued so far, under AsyncQueueSize, have been written.
+func (l *Logger) Flush() {
+	q := l.async
+	if q == nil {
+		return
+	}
+	q.pendingMu.Lock()
+	defer q.pendingMu.Unlock()
+
+	for q.pending > 0 {
+		q.idle.Wait()
+	}
+}
+
+// Close stops the background activity of the Logger, for graceful shutdown: it stops watching ProbePaths, logs the entries suppressed by RateLimit since the last summary, and waits until the entries queued under AsyncQueueSize have been written, or ctx is done. Entries of requests completing after Close are written synchronously.
+func (l *Logger) Close(ctx context.Context) error {
+	if l.probes != nil {
+		l.probes.stop()
+	}
+	if l.limiter != nil {
+		l.limiter.stop()
+	}
+
+	q := l.async
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.entries)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// writeQueued writes the queued entries, until the queue is closed and drained.
+func (l *Logger) writeQueued() {
+	defer l.async.workers.Done()
+
+	for e := range l.async.entries {
+		if !e.deadline.IsZero() && time.Now().After(e.deadline) {
+			atomic.AddUint64(&l.async.expired, 1)
+			e.release()
+		} else {
+			e.write()
+		}
+		l.async.addPending(-1)
+	}
+}
+
+// write writes e, and recycles its pooled fields, which logrus copies into the entries it hands to hooks and formatters.
+func (e logEntry) write() {
+	entry := &logrus.Entry{Logger: e.logger, Data: e.fields, Time: e.time}
+	entry.Log(e.level, e.message)
+	for _, logger := range e.tee {
+		entry.Logger = logger
+		entry.Log(e.level, e.message)
+	}
+	e.release()
+}
+
+// fieldsPool recycles the fields of written entries. They are sized for the standard fields and a typical set of optional ones.
+var fieldsPool = sync.Pool{
+	New: func() interface{} {
+		return make(logrus.Fields, 32)
+	},
+}
+
+// newFields returns an empty fields map, to be released once written.
+func newFields() logrus.Fields {
+	return fieldsPool.Get().(logrus.Fields)
+}
+
+// release recycles the fields of e, if they came from newFields.
+func (e logEntry) release() {
+	if !e.pooled || e.fields == nil {
+		return
+	}
+	for key := range e.fields {
+		delete(e.fields, key)
+	}
+	fieldsPool.Put(e.fields)
+}
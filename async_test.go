@@ -0,0 +1,312 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// chanWriter sends everything written to it on a channel.
+type chanWriter chan string
+
+func (w chanWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestAsync(t *testing.T) {
+	out := make(chanWriter)
+	logger := logrus.New()
+	logger.SetOutput(out)
+
+	l := New(Options{
+		Logger:         logger,
+		AsyncQueueSize: 1,
+		AsyncWorkers:   2,
+	})
+
+	// Requests are served before their entries are written.
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+	l.Handler(myHandler).ServeHTTP(res, req)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	for i := 0; i < 3; i++ {
+		select {
+		case line := <-out:
+			expectContainsTrue(t, line, "http_status=200")
+		case <-time.After(time.Second):
+			t.Fatal("Expected a queued entry to be written")
+		}
+	}
+	select {
+	case line := <-out:
+		t.Errorf("Expected no more entries - Got [%v]", strings.TrimSpace(line))
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// Entries are timestamped when the request completes, not when they are written.
+	l.emit(logEntry{logger: logger, time: time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC), level: logrus.InfoLevel})
+	expectContainsTrue(t, <-out, "time=\"2009-11-10T23:00:00Z\"")
+}
+
+func TestAsyncDrop(t *testing.T) {
+	out := make(chanWriter)
+	logger := logrus.New()
+	logger.SetOutput(out)
+
+	l := New(Options{
+		Logger:         logger,
+		AsyncQueueSize: 1,
+		AsyncQueueFull: AsyncDrop,
+	})
+
+	// The worker holds the first entry, the queue the second one.
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+	for len(l.async.entries) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	l.Handler(myHandler).ServeHTTP(res, req)
+	l.Handler(myHandler).ServeHTTP(res, req)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expect(t, l.Dropped(), uint64(2))
+	<-out
+	<-out
+}
+
+func TestAsyncEntryTimeout(t *testing.T) {
+	out := make(chanWriter)
+	logger := logrus.New()
+	logger.SetOutput(out)
+
+	l := New(Options{
+		Logger:            logger,
+		AsyncQueueSize:    2,
+		AsyncEntryTimeout: 10 * time.Millisecond,
+	})
+
+	// The worker holds the first entry while the next ones expire in the queue.
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+	for len(l.async.entries) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	l.Handler(myHandler).ServeHTTP(res, req)
+	l.Handler(myHandler).ServeHTTP(res, req)
+	time.Sleep(20 * time.Millisecond)
+	<-out
+	l.Flush()
+
+	expect(t, l.Expired(), uint64(2))
+	expect(t, l.Dropped(), uint64(0))
+
+	// Entries written in time are not expired.
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, <-out, "http_status=200")
+	l.Flush()
+	expect(t, l.Expired(), uint64(2))
+}
+
+func TestAsyncSync(t *testing.T) {
+	out := make(chanWriter)
+	logger := logrus.New()
+	logger.SetOutput(out)
+	errOut := &syncBuffer{}
+	errLogger := logrus.New()
+	errLogger.SetOutput(errOut)
+
+	l := New(Options{
+		Logger:         logger,
+		ErrorLogger:    errLogger,
+		AsyncQueueSize: 1,
+		AsyncQueueFull: AsyncDrop,
+		AsyncSync:      AsyncSyncCritical,
+	})
+
+	// The worker holds the first entry, the queue the second one.
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+	for len(l.async.entries) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	// The 5xx entry is written before the middleware returns, although the queue is full.
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	expectContainsTrue(t, errOut.String(), "http_status=500")
+
+	// So is the entry of a panicking handler, before the panic resumes.
+	func() {
+		defer func() {
+			expect(t, recover(), "boom")
+		}()
+		l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})).ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	expectContainsTrue(t, errOut.String(), "http_panic=boom")
+
+	expect(t, l.Dropped(), uint64(0))
+	<-out
+	<-out
+}
+
+func TestAsyncSyncRoute(t *testing.T) {
+	out := &syncBuffer{}
+	logger := logrus.New()
+	logger.SetOutput(out)
+	errOut := make(chanWriter)
+	errLogger := logrus.New()
+	errLogger.SetOutput(errOut)
+
+	l := New(Options{
+		Logger:         logger,
+		ErrorLogger:    errLogger,
+		AsyncQueueSize: 1,
+		AsyncQueueFull: AsyncDrop,
+		AsyncSync: func(r *http.Request, status int, level logrus.Level) bool {
+			return r != nil && strings.HasPrefix(r.URL.Path, "/audit/")
+		},
+	})
+
+	// The worker holds the first entry, the queue the second one.
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandlerWithError).ServeHTTP(httptest.NewRecorder(), req)
+	for len(l.async.entries) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	l.Handler(myHandlerWithError).ServeHTTP(httptest.NewRecorder(), req)
+
+	// Entries of audit routes are written before the middleware returns, others are dropped.
+	l.Handler(myHandler).ServeHTTP(httptest.NewRecorder(), req)
+	audit, _ := http.NewRequest("POST", "/audit/login", nil)
+	l.Handler(myHandler).ServeHTTP(httptest.NewRecorder(), audit)
+
+	expectContainsTrue(t, out.String(), "http_method=POST")
+	expect(t, strings.Count(out.String(), "http_status=200"), 1)
+	expect(t, l.Dropped(), uint64(1))
+	<-errOut
+	<-errOut
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestFlushAndClose(t *testing.T) {
+	buf := &syncBuffer{}
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:         logger,
+		AsyncQueueSize: 100,
+		AsyncWorkers:   4,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	for i := 0; i < 50; i++ {
+		l.Handler(myHandler).ServeHTTP(res, req)
+	}
+	l.Flush()
+	expect(t, strings.Count(buf.String(), "Request received"), 50)
+
+	for i := 0; i < 50; i++ {
+		l.Handler(myHandler).ServeHTTP(res, req)
+	}
+	expect(t, l.Close(context.Background()), nil)
+	expect(t, strings.Count(buf.String(), "Request received"), 100)
+
+	// Entries are written synchronously once closed.
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expect(t, strings.Count(buf.String(), "Request received"), 101)
+	expect(t, l.Close(context.Background()), nil)
+}
+
+func TestCloseTimeout(t *testing.T) {
+	out := make(chanWriter)
+	logger := logrus.New()
+	logger.SetOutput(out)
+
+	l := New(Options{
+		Logger:         logger,
+		AsyncQueueSize: 1,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	expect(t, l.Close(ctx), context.DeadlineExceeded)
+	<-out
+}
+
+// dataHook keeps the data of the entries it fires on.
+type dataHook []logrus.Fields
+
+func (h *dataHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *dataHook) Fire(entry *logrus.Entry) error {
+	*h = append(*h, entry.Data)
+	return nil
+}
+
+func TestFieldsRecycled(t *testing.T) {
+	hook := &dataHook{}
+	logger := logrus.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	logger.AddHook(hook)
+
+	l := New(Options{
+		Logger:       logger,
+		CustomFields: logrus.Fields{FieldMethod: "overridden"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RequestURI = "/foo"
+	l.Handler(myHandler).ServeHTTP(res, req)
+	req, _ = http.NewRequest("GET", "/bar", nil)
+	req.RequestURI = "/bar"
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	// Hooks keep the fields of entries after their map is recycled.
+	expect(t, len(*hook), 2)
+	expect(t, (*hook)[0][FieldURI], "/foo")
+	expect(t, (*hook)[1][FieldURI], "/bar")
+	expect(t, (*hook)[0][FieldMethod], "overridden")
+}
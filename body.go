@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// requestBody wraps a request body to observe how the handler reads it.
+type requestBody struct {
+	io.ReadCloser
+	start time.Time
+	// read is set on the first Read, firstByte is the time between the start of the request and the first byte read.
+	read      bool
+	firstByte time.Duration
+	// size counts the bytes read, eof is set once the body is read entirely.
+	size int64
+	eof  bool
+	// readTime is the time spent in Read, when timed is set.
+	timed    bool
+	readTime time.Duration
+}
+
+func (b *requestBody) Read(p []byte) (int, error) {
+	var t time.Time
+	if b.timed {
+		t = time.Now()
+	}
+	b.read = true
+	n, err := b.ReadCloser.Read(p)
+	if b.timed {
+		b.readTime += time.Since(t)
+	}
+	if n > 0 && b.firstByte == 0 {
+		b.firstByte = time.Since(b.start)
+	}
+	b.size += int64(n)
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+// expectsContinue reports whether the client of r waits for a 100 Continue response before sending the body.
+func expectsContinue(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && strings.EqualFold(strings.TrimSpace(r.Header.Get("Expect")), "100-continue")
+}
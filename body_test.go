@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestExpectContinue(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/upload", strings.NewReader("payload"))
+	req.Header.Set("Expect", "100-Continue")
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Write(b)
+	})).ServeHTTP(res, req)
+
+	expect(t, res.Body.String(), "payload")
+	expectContainsTrue(t, buf.String(), "http_expect_continue=true")
+	expectContainsTrue(t, buf.String(), "http_continue_sent=true")
+	expectContainsTrue(t, buf.String(), "http_continue_wait=")
+}
+
+func TestExpectContinueRejected(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/upload", strings.NewReader("payload"))
+	req.Header.Set("Expect", "100-continue")
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_expect_continue=true")
+	expectContainsTrue(t, buf.String(), "http_continue_sent=false")
+	expectContainsFalse(t, buf.String(), "http_continue_wait")
+}
+
+func TestNoExpectContinue(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/upload", strings.NewReader("payload"))
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), "http_expect_continue")
+}
+
+func TestLogRequestSize(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:         logger,
+		LogRequestSize: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/upload", strings.NewReader("payload"))
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b := make([]byte, 3)
+		r.Body.Read(b)
+		w.Write(b)
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_request_length=7")
+	expectContainsTrue(t, buf.String(), "http_request_size=3")
+	expectContainsFalse(t, buf.String(), "http_expect_continue")
+
+	buf.Reset()
+	req, _ = http.NewRequest("GET", "/", http.NoBody)
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsFalse(t, buf.String(), "http_request_length")
+	expectContainsFalse(t, buf.String(), "http_request_size")
+}
+
+func TestLogRequestBodyRead(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:             logger,
+		LogRequestBodyRead: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/upload", strings.NewReader("payload"))
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_request_read_time=")
+	expectContainsTrue(t, buf.String(), "http_request_consumed=true")
+
+	buf.Reset()
+	req, _ = http.NewRequest("PUT", "/upload", strings.NewReader("payload"))
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "http_request_read_time=0s")
+	expectContainsTrue(t, buf.String(), "http_request_consumed=false")
+}
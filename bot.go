@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// botFields adds the normalized bot verification headers of r to fields. Signatures are neither verified, nor is the claimed identity checked: the fields let bot-management decisions be audited.
+func botFields(fields logrus.Fields, r *http.Request, now time.Time) {
+	// Web Bot Auth signed requests.
+	if agent := r.Header.Get("Signature-Agent"); len(agent) > 0 {
+		fields[FieldBotSignatureAgent] = strings.Trim(strings.TrimSpace(agent), `"`)
+	}
+	if input := r.Header.Get("Signature-Input"); len(input) > 0 {
+		params := signatureParams(input)
+		if keyID, ok := params["keyid"]; ok {
+			fields[FieldBotSignatureKeyID] = keyID
+		}
+		if tag, ok := params["tag"]; ok {
+			fields[FieldBotSignatureTag] = tag
+		}
+		if expires, err := strconv.ParseInt(params["expires"], 10, 64); err == nil {
+			fields[FieldBotSignatureExpired] = now.Unix() > expires
+		}
+	}
+
+	// Cloudflare bot management, when forwarded to the origin.
+	if verified, err := strconv.ParseBool(r.Header.Get("Cf-Verified-Bot")); err == nil {
+		fields[FieldBotVerified] = verified
+	}
+	if score, err := strconv.Atoi(strings.TrimSpace(r.Header.Get("Cf-Bot-Score"))); err == nil {
+		fields[FieldBotScore] = score
+	}
+
+	// Well-behaved crawlers, like Googlebot, name their operator in From.
+	if from := r.Header.Get("From"); len(from) > 0 {
+		fields[FieldBotFrom] = from
+	}
+}
+
+// signatureParams returns the parameters of the first signature of an HTTP Message Signatures Signature-Input header, e.g. `sig1=("@authority");created=1735689600;keyid="abc";tag="web-bot-auth"`.
+func signatureParams(input string) map[string]string {
+	if i := strings.IndexByte(input, ')'); i >= 0 {
+		input = input[i+1:]
+	}
+	if i := strings.IndexByte(input, ','); i >= 0 {
+		input = input[:i]
+	}
+
+	params := make(map[string]string)
+	for _, param := range strings.Split(input, ";") {
+		eq := strings.IndexByte(param, '=')
+		if eq < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(param[:eq]))
+		params[key] = strings.Trim(strings.TrimSpace(param[eq+1:]), `"`)
+	}
+	return params
+}
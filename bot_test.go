@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSignatureParams(t *testing.T) {
+	params := signatureParams(`sig1=("@authority" "signature-agent");created=1735689600;keyid="poqkLGiym6";alg="ed25519";expires=1735693200;tag="web-bot-auth", sig2=();keyid="other"`)
+
+	expect(t, params["keyid"], "poqkLGiym6")
+	expect(t, params["tag"], "web-bot-auth")
+	expect(t, params["created"], "1735689600")
+	expect(t, params["expires"], "1735693200")
+	expect(t, len(signatureParams("garbage")), 0)
+}
+
+func TestBotHeaders(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:     logger,
+		BotHeaders: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Signature-Agent", `"https://crawler.example.com"`)
+	req.Header.Set("Signature-Input", `sig1=("@authority");created=1735689600;keyid="abc";expires=1735693200;tag="web-bot-auth"`)
+	req.Header.Set("CF-Verified-Bot", "true")
+	req.Header.Set("CF-Bot-Score", "2")
+	req.Header.Set("From", "googlebot(at)googlebot.com")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "bot_signature_agent=\"https://crawler.example.com\"")
+	expectContainsTrue(t, buf.String(), "bot_signature_keyid=abc")
+	expectContainsTrue(t, buf.String(), "bot_signature_tag=web-bot-auth")
+	expectContainsTrue(t, buf.String(), "bot_signature_expired=true")
+	expectContainsTrue(t, buf.String(), "bot_verified=true")
+	expectContainsTrue(t, buf.String(), "bot_score=2")
+	expectContainsTrue(t, buf.String(), "bot_from=\"googlebot(at)googlebot.com\"")
+}
+
+func TestBotHeadersAbsent(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:     logger,
+		BotHeaders: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("CF-Bot-Score", "high")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), "bot_")
+}
@@ -0,0 +1,11 @@
+//go:build !go1.21
+// +build !go1.21
+
+package logger
+
+import "context"
+
+// cancelCause returns the cause ctx was canceled with. Contexts carry no cause before Go 1.21, so it is always nil.
+func cancelCause(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build go1.21
+// +build go1.21
+
+package logger
+
+import "context"
+
+// cancelCause returns the cause ctx was canceled with, if other than context.Canceled.
+func cancelCause(ctx context.Context) error {
+	if err := context.Cause(ctx); err != context.Canceled {
+		return err
+	}
+	return nil
+}
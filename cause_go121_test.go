@@ -0,0 +1,36 @@
+//go:build go1.21
+// +build go1.21
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestCancelCause(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req = req.WithContext(ctx)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel(errors.New("stream reset"))
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_client_disconnected=true")
+	expectContainsTrue(t, buf.String(), `http_cancel_cause="stream reset"`)
+}
@@ -0,0 +1,11 @@
+//go:build !go1.14
+// +build !go1.14
+
+package logger
+
+import "fmt"
+
+// cipherSuiteName returns the name of the TLS cipher suite id. Cipher suites have no standard names before Go 1.14, so it is always the hex value of id, e.g. "0x1301".
+func cipherSuiteName(id uint16) string {
+	return fmt.Sprintf("0x%04X", id)
+}
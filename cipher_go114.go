@@ -0,0 +1,11 @@
+//go:build go1.14
+// +build go1.14
+
+package logger
+
+import "crypto/tls"
+
+// cipherSuiteName returns the name of the TLS cipher suite id, e.g. "TLS_AES_128_GCM_SHA256".
+func cipherSuiteName(id uint16) string {
+	return tls.CipherSuiteName(id)
+}
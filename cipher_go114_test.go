@@ -0,0 +1,27 @@
+//go:build go1.14
+// +build go1.14
+
+package logger
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCipherSuiteName(t *testing.T) {
+	expect(t, cipherSuiteName(tls.TLS_AES_128_GCM_SHA256), "TLS_AES_128_GCM_SHA256")
+	expect(t, cipherSuiteName(0x0a0a), "0x0A0A")
+}
+
+func TestAppendALBCipher(t *testing.T) {
+	l := New()
+	req, _ := http.NewRequest("GET", "https://example.com/foo", nil)
+	req.RequestURI = "/foo"
+	req.TLS = &tls.ConnectionState{Version: tls.VersionTLS13, CipherSuite: tls.TLS_AES_128_GCM_SHA256}
+	line := appendALB(nil, l, req, &customResponseWriter{status: 200}, "192.168.131.39:2817", time.Now(), time.Millisecond)
+	expectContainsTrue(t, string(line), " TLS_AES_128_GCM_SHA256 TLSv1.3 ")
+	expect(t, strings.HasPrefix(string(line), "https "), true)
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CloudProjectEnv is the environment variable consulted for the Google Cloud project ID when Options.CloudProject is empty.
+const CloudProjectEnv = "GOOGLE_CLOUD_PROJECT"
+
+// cloudNestedFields are the standard fields moved under `httpRequest` by CloudLogging.
+var cloudNestedFields = []string{FieldAddr, FieldMethod, FieldURI, FieldProto, FieldStatus, FieldSize, FieldDuration, FieldPath, FieldQuery, FieldReferer, FieldUserAgent}
+
+// cloudSeverities maps logrus levels to Cloud Logging severities.
+var cloudSeverities = map[logrus.Level]string{
+	logrus.PanicLevel: "EMERGENCY",
+	logrus.FatalLevel: "CRITICAL",
+	logrus.ErrorLevel: "ERROR",
+	logrus.WarnLevel:  "WARNING",
+	logrus.InfoLevel:  "INFO",
+	logrus.DebugLevel: "DEBUG",
+	logrus.TraceLevel: "DEBUG",
+}
+
+// cloudTrace is a parsed X-Cloud-Trace-Context header.
+type cloudTrace struct {
+	traceID string
+	spanID  string
+	sampled bool
+}
+
+// parseCloudTraceContext parses an X-Cloud-Trace-Context header, e.g. "105445aa7843bc8bf206b12000100000/1;o=1", whose span ID is decimal.
+func parseCloudTraceContext(header string) (cloudTrace, bool) {
+	header, options := strings.TrimSpace(header), ""
+	if i := strings.IndexByte(header, ';'); i >= 0 {
+		header, options = header[:i], header[i+1:]
+	}
+	traceID, spanID := header, ""
+	if i := strings.IndexByte(header, '/'); i >= 0 {
+		traceID, spanID = header[:i], header[i+1:]
+	}
+	if !isLowerHex(strings.ToLower(traceID), 32) {
+		return cloudTrace{}, false
+	}
+	ct := cloudTrace{traceID: strings.ToLower(traceID), sampled: options == "o=1"}
+	if id, err := strconv.ParseUint(spanID, 10, 64); err == nil && id > 0 {
+		ct.spanID = fmt.Sprintf("%016x", id)
+	}
+	return ct, true
+}
+
+// cloudLoggingFields moves the request fields of fields under `httpRequest`, as the Cloud Logging LogEntry expects, and adds the severity of level and the trace of r, if any. The address, user agent and referer are only moved if they are logged.
+func (l *Logger) cloudLoggingFields(fields logrus.Fields, r *http.Request, crw *customResponseWriter, duration time.Duration, level logrus.Level, tp traceParent, traced bool) {
+	req := map[string]interface{}{
+		"requestMethod": r.Method,
+		"requestUrl":    redactQuery(r.RequestURI, l.redactedParams),
+		"status":        crw.status,
+		"responseSize":  strconv.Itoa(crw.size),
+		"latency":       strconv.FormatFloat(duration.Seconds(), 'f', -1, 64) + "s",
+		"protocol":      r.Proto,
+	}
+	if addr, ok := fields[FieldAddr].(string); ok && len(addr) > 0 {
+		req["remoteIp"] = addr
+	}
+	if r.ContentLength > 0 {
+		req["requestSize"] = strconv.FormatInt(r.ContentLength, 10)
+	}
+	if ua, ok := fields[FieldUserAgent]; ok {
+		req["userAgent"] = ua
+	}
+	if referer, ok := fields[FieldReferer]; ok {
+		req["referer"] = referer
+	}
+	for _, key := range cloudNestedFields {
+		delete(fields, key)
+	}
+	fields[FieldCloudHTTPRequest] = req
+	fields[FieldCloudSeverity] = cloudSeverities[level]
+
+	ct, ok := parseCloudTraceContext(r.Header.Get("X-Cloud-Trace-Context"))
+	if !ok && traced {
+		ct, ok = cloudTrace{traceID: tp.traceID, spanID: tp.spanID, sampled: tp.sampled}, true
+	}
+	if !ok {
+		return
+	}
+	if len(l.opt.CloudProject) > 0 {
+		fields[FieldCloudTrace] = "projects/" + l.opt.CloudProject + "/traces/" + ct.traceID
+	} else {
+		fields[FieldCloudTrace] = ct.traceID
+	}
+	if len(ct.spanID) > 0 {
+		fields[FieldCloudSpanID] = ct.spanID
+	}
+	fields[FieldCloudTraceSampled] = ct.sampled
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseCloudTraceContext(t *testing.T) {
+	ct, ok := parseCloudTraceContext("105445aa7843bc8bf206b12000100000/1;o=1")
+	expect(t, ok, true)
+	expect(t, ct.traceID, "105445aa7843bc8bf206b12000100000")
+	expect(t, ct.spanID, "0000000000000001")
+	expect(t, ct.sampled, true)
+
+	ct, ok = parseCloudTraceContext("105445AA7843BC8BF206B12000100000")
+	expect(t, ok, true)
+	expect(t, ct.traceID, "105445aa7843bc8bf206b12000100000")
+	expect(t, ct.spanID, "")
+	expect(t, ct.sampled, false)
+
+	_, ok = parseCloudTraceContext("nope/1;o=1")
+	expect(t, ok, false)
+}
+
+func TestCloudLogging(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	l := New(Options{
+		Logger:       logger,
+		Preset:       "gcp",
+		CloudProject: "my-project",
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo?bar=baz", nil)
+	req.RequestURI = "/foo?bar=baz"
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("User-Agent", "curl/7.64.1")
+	req.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	l.Handler(myHandlerWithError).ServeHTTP(res, req)
+
+	var entry struct {
+		HTTPRequest  map[string]interface{} `json:"httpRequest"`
+		Severity     string                 `json:"severity"`
+		Trace        string                 `json:"logging.googleapis.com/trace"`
+		SpanID       string                 `json:"logging.googleapis.com/spanId"`
+		TraceSampled bool                   `json:"logging.googleapis.com/trace_sampled"`
+		Status       interface{}            `json:"http_status"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, entry.HTTPRequest["requestMethod"], "GET")
+	expect(t, entry.HTTPRequest["requestUrl"], "/foo?bar=baz")
+	expect(t, entry.HTTPRequest["status"], float64(502))
+	expect(t, entry.HTTPRequest["remoteIp"], "10.0.0.1")
+	expect(t, entry.HTTPRequest["userAgent"], "curl/7.64.1")
+	expect(t, entry.Severity, "ERROR")
+	expect(t, entry.Trace, "projects/my-project/traces/105445aa7843bc8bf206b12000100000")
+	expect(t, entry.SpanID, "0000000000000001")
+	expect(t, entry.TraceSampled, true)
+	expect(t, entry.Status, nil)
+}
+
+func TestCloudLoggingTraceParent(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	l := New(Options{
+		Logger:       logger,
+		CloudLogging: true,
+		CloudProject: "my-project",
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	var entry struct {
+		Trace        string `json:"logging.googleapis.com/trace"`
+		SpanID       string `json:"logging.googleapis.com/spanId"`
+		TraceSampled bool   `json:"logging.googleapis.com/trace_sampled"`
+		TraceID      string `json:"trace_id"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, entry.Trace, "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736")
+	expect(t, entry.SpanID, "00f067aa0ba902b7")
+	expect(t, entry.TraceSampled, true)
+	expect(t, entry.TraceID, "")
+}
@@ -0,0 +1,40 @@
+// Command logger-selftest prints the entries the logger middleware writes for representative requests, so that a configuration can be validated before it is deployed.
+//
+//	logger-selftest -preset security -json
+//
+// The preset defaults to the value of the LOGGER_PRESET environment variable.
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/ant1441/logger-logrus"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	preset := flag.String("preset", "", "name of the preset to test: "+strings.Join(logger.Presets(), ", "))
+	json := flag.Bool("json", false, "write JSON entries instead of logfmt ones")
+	ignored := flag.String("ignore", "", "comma separated list of ignored request URIs")
+	flag.Parse()
+
+	log := logrus.New()
+	log.Out = os.Stdout
+	if *json {
+		log.Formatter = &logrus.JSONFormatter{}
+	} else {
+		log.Formatter = &logrus.TextFormatter{DisableColors: true}
+	}
+
+	opt := logger.Options{
+		Logger: log,
+		Preset: *preset,
+	}
+	if len(*ignored) > 0 {
+		opt.IgnoredRequestURIs = strings.Split(*ignored, ",")
+	}
+
+	logger.New(opt).SelfTest(os.Stdout)
+}
@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// debugRequest reports whether r asks to be logged verbosely, with DebugSecret as its DebugHeader value, or with any value from DebugNetworks.
+func (l *Logger) debugRequest(r *http.Request) bool {
+	val := r.Header.Get(l.opt.DebugHeader)
+	if len(val) == 0 {
+		return false
+	}
+	if len(l.opt.DebugSecret) > 0 && subtle.ConstantTimeCompare([]byte(val), []byte(l.opt.DebugSecret)) == 1 {
+		return true
+	}
+	if len(l.opt.DebugNetworks) > 0 {
+		if ip := parseAddrIP(r.RemoteAddr); ip != nil && containsIP(l.opt.DebugNetworks, ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// debugFields adds the verbose fields of r to fields: every request and response header, redacted, and the captured response body.
+func (l *Logger) debugFields(fields logrus.Fields, r *http.Request, crw *customResponseWriter) {
+	fields[FieldDebug] = true
+	l.allHeaderFields(fields, r, crw)
+	if len(crw.body) > 0 {
+		l.payloadFields(fields, FieldResponseBody, crw.body)
+	}
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestDebugHeader(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	_, vpn, _ := net.ParseCIDR("10.8.0.0/16")
+	l := New(Options{
+		Logger:             logger,
+		IgnoredRequestURIs: []string{"/foo"},
+		DebugHeader:        "X-Debug-Log",
+		DebugSecret:        "s3cr3t",
+		DebugNetworks:      []net.IPNet{*vpn},
+		DebugBodySize:      2,
+	})
+	handler := l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Served-By", "test")
+		w.Write([]byte("bar"))
+	}))
+
+	for _, c := range []struct {
+		remoteAddr string
+		value      string
+		debug      bool
+	}{
+		{"192.0.2.1:1234", "", false},
+		{"192.0.2.1:1234", "wrong", false},
+		{"192.0.2.1:1234", "s3cr3t", true},
+		{"10.8.1.2:1234", "1", true},
+	} {
+		buf.Reset()
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		req.RequestURI = "/foo"
+		req.RemoteAddr = c.remoteAddr
+		req.Header.Set("Accept", "text/plain")
+		req.Header.Set("Cookie", "session=abc")
+		if len(c.value) > 0 {
+			req.Header.Set("X-Debug-Log", c.value)
+		}
+		handler.ServeHTTP(res, req)
+		expect(t, res.Body.String(), "bar")
+
+		if !c.debug {
+			expect(t, buf.String(), "")
+			continue
+		}
+		expectContainsTrue(t, buf.String(), "debug=true")
+		expectContainsTrue(t, buf.String(), "http_req_accept=text/plain")
+		expectContainsTrue(t, buf.String(), "http_req_cookie=REDACTED")
+		expectContainsTrue(t, buf.String(), "http_req_x_debug_log=REDACTED")
+		expectContainsTrue(t, buf.String(), "http_resp_x_served_by=test")
+		expectContainsTrue(t, buf.String(), "http_response_body=ba ")
+	}
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"net/http"
+	"sync"
+)
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger *Logger
+)
+
+// SetDefault sets the process-wide Logger used by DefaultHandler. Requests already being served keep the Logger they started with.
+func SetDefault(l *Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLogger = l
+}
+
+// Default returns the process-wide Logger, as set by SetDefault. If none was set, a Logger with the default options is created on first use.
+func Default() *Logger {
+	defaultMu.RLock()
+	l := defaultLogger
+	defaultMu.RUnlock()
+	if l != nil {
+		return l
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultLogger == nil {
+		defaultLogger = New()
+	}
+	return defaultLogger
+}
+
+// DefaultHandler wraps an HTTP handler and logs the request with the process-wide Logger, as returned by Default at the time of the request. This lets libraries and sub-routers attach the middleware without a Logger being plumbed through to them.
+func DefaultHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Default().serve(w, r, next)
+	})
+}
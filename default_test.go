@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestDefault(t *testing.T) {
+	defer SetDefault(nil)
+
+	SetDefault(nil)
+	l := Default()
+	expect(t, l.opt.Logger, logrus.StandardLogger())
+	expect(t, Default(), l)
+}
+
+func TestDefaultHandler(t *testing.T) {
+	defer SetDefault(nil)
+
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	// The handler is attached before the default Logger is set.
+	handler := DefaultHandler(myHandler)
+	SetDefault(New(Options{
+		Logger: logger,
+	}))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	handler.ServeHTTP(res, req)
+
+	expect(t, res.Body.String(), "bar")
+	expectContainsTrue(t, buf.String(), "http_method=GET")
+}
@@ -26,6 +26,6 @@
 
 A simple GET request to "/info/" will output:
 
-  INFO[0013] Request received                              http_addr="127.0.0.1:41634" http_duration="4.511µs" http_method=GET http_proto=HTTP/1.1 http_size=11 http_status=200 http_uri=/info
+  INFO[0013] Request received                              http_addr="127.0.0.1:41634" http_duration="4.511µs" http_host="localhost:3000" http_method=GET http_proto=HTTP/1.1 http_scheme=http http_size=11 http_status=200 http_ttfb="3.902µs" http_uri=/info
 */
 package logger
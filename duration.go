@@ -0,0 +1,39 @@
+package logger
+
+import "time"
+
+// DurationFormat selects how durations, such as `http_duration` and `http_ttfb`, are logged.
+type DurationFormat int
+
+const (
+	// DurationString logs durations as Go duration strings, e.g. "4.511µs".
+	DurationString DurationFormat = iota
+	// DurationNanoseconds logs durations as integer numbers of nanoseconds, e.g. 4511.
+	DurationNanoseconds
+	// DurationMicroseconds logs durations as integer numbers of microseconds, truncated, e.g. 4.
+	DurationMicroseconds
+	// DurationMilliseconds logs durations as integer numbers of milliseconds, truncated, e.g. 0.
+	DurationMilliseconds
+	// DurationMillisecondsFloat logs durations as numbers of milliseconds with microsecond precision, e.g. 0.004.
+	DurationMillisecondsFloat
+)
+
+// Format returns d as it is logged in format f, e.g. by adapters logging `http_duration` themselves.
+func (f DurationFormat) Format(d time.Duration) interface{} {
+	return formatDuration(d, f)
+}
+
+// formatDuration returns d as it is logged in format.
+func formatDuration(d time.Duration, format DurationFormat) interface{} {
+	switch format {
+	case DurationNanoseconds:
+		return d.Nanoseconds()
+	case DurationMicroseconds:
+		return int64(d / time.Microsecond)
+	case DurationMilliseconds:
+		return int64(d / time.Millisecond)
+	case DurationMillisecondsFloat:
+		return float64(d/time.Microsecond) / 1000
+	}
+	return d
+}
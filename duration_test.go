@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFormatDuration(t *testing.T) {
+	d := 4511 * time.Microsecond
+	expect(t, formatDuration(d, DurationString), d)
+	expect(t, formatDuration(d, DurationNanoseconds), int64(4511000))
+	expect(t, formatDuration(d, DurationMicroseconds), int64(4511))
+	expect(t, formatDuration(d, DurationMilliseconds), int64(4))
+	expect(t, formatDuration(d+999, DurationMillisecondsFloat), 4.511)
+	expect(t, DurationMilliseconds.Format(d), int64(4))
+}
+
+func TestDurationFormat(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:         logger,
+		DurationFormat: DurationMilliseconds,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_duration=0 ")
+	expectContainsTrue(t, buf.String(), "http_ttfb=0 ")
+}
+
+func TestDurationSeconds(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:          logger,
+		DurationSeconds: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_duration_seconds=")
+	expectContainsTrue(t, buf.String(), "http_duration=")
+}
+
+func TestDurationSecondsValue(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	logger.Formatter = &logrus.JSONFormatter{}
+
+	l := New(Options{
+		Logger:          logger,
+		DurationSeconds: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	})).ServeHTTP(res, req)
+
+	var entry struct {
+		Seconds float64 `json:"http_duration_seconds"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Seconds < 0.01 || entry.Seconds > 1 {
+		t.Errorf("Expected a duration of about 0.01 seconds - Got [%v]", entry.Seconds)
+	}
+}
@@ -0,0 +1,58 @@
+// Package echologger adapts the logger middleware to Echo, so that Echo services log requests with the same Options and fields as net/http ones.
+package echologger
+
+import (
+	"net/http"
+
+	"github.com/ant1441/logger-logrus"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// ParamPrefix is prepended to the names of the path parameters of requests to form the keys they are logged under, e.g. `http_param_id` for "/users/:id".
+const ParamPrefix = "http_param_"
+
+// Echo returns an Echo middleware logging requests as logger.New(opts...) does, with the Echo route path, e.g. "/users/:id", as `http_route` and its path parameters prefixed with ParamPrefix.
+func Echo(opts ...logger.Options) echo.MiddlewareFunc {
+	return Middleware(logger.New(opts...))
+}
+
+// Middleware returns an Echo middleware logging requests with l, so that it can be shared with net/http handlers or reconfigured with SetOptions.
+func Middleware(l *logger.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			res := c.Response()
+			l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				rw := res.Writer
+				defer func() { res.Writer = rw }()
+
+				res.Writer = w
+				c.SetRequest(r)
+				// Errors are handled now, rather than by Echo once the middleware returns, so that their response is logged.
+				if err = next(c); err != nil {
+					c.Error(err)
+				}
+				routeFields(r, c)
+			})).ServeHTTP(res.Writer, c.Request())
+			return err
+		}
+	}
+}
+
+// routeFields records the route and the path parameters of c in the entry of r.
+func routeFields(r *http.Request, c echo.Context) {
+	if route := c.Path(); len(route) > 0 {
+		logger.SetRoute(r.Context(), route)
+	}
+	names, values := c.ParamNames(), c.ParamValues()
+	if len(names) == 0 {
+		return
+	}
+	fields := make(logrus.Fields, len(names))
+	for i, name := range names {
+		if i < len(values) {
+			fields[ParamPrefix+name] = values[i]
+		}
+	}
+	logger.AddFields(r.Context(), fields)
+}
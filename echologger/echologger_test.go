@@ -0,0 +1,72 @@
+package echologger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ant1441/logger-logrus"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+func serve(e *echo.Echo, method, uri string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, uri, nil)
+	req.RequestURI = uri
+	e.ServeHTTP(res, req)
+	return res
+}
+
+func TestEcho(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	log := logrus.New()
+	log.SetOutput(buf)
+
+	e := echo.New()
+	e.Use(Echo(logger.Options{Logger: log}))
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.String(http.StatusCreated, "user "+c.Param("id"))
+	})
+
+	res := serve(e, "GET", "/users/42")
+	if res.Code != http.StatusCreated || res.Body.String() != "user 42" {
+		t.Fatalf("got %d %q", res.Code, res.Body.String())
+	}
+	for _, want := range []string{"http_route=\"/users/:id\"", "http_param_id=42", "http_uri=/users/42", "http_status=201", "http_size=7"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%q not found in %q", want, buf.String())
+		}
+	}
+}
+
+func TestEchoError(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	log := logrus.New()
+	log.SetOutput(buf)
+
+	e := echo.New()
+	e.Use(Middleware(logger.New(logger.Options{Logger: log})))
+	e.GET("/teapot", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusTeapot, "short and stout")
+	})
+
+	res := serve(e, "GET", "/teapot")
+	if res.Code != http.StatusTeapot {
+		t.Fatalf("got %d", res.Code)
+	}
+	res = serve(e, "GET", "/missing")
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("got %d", res.Code)
+	}
+	for _, want := range []string{"http_status=418", "http_route=/teapot", "http_status=404"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%q not found in %q", want, buf.String())
+		}
+	}
+	if strings.Count(buf.String(), "Request received") != 2 {
+		t.Errorf("expected 2 entries in %q", buf.String())
+	}
+}
@@ -0,0 +1,23 @@
+module github.com/ant1441/logger-logrus/echologger
+
+go 1.25.0
+
+require (
+	github.com/ant1441/logger-logrus v0.0.0
+	github.com/labstack/echo/v4 v4.15.4
+	github.com/sirupsen/logrus v1.10.2
+)
+
+require (
+	github.com/labstack/gommon v0.5.0 // indirect
+	github.com/mattn/go-colorable v0.1.15 // indirect
+	github.com/mattn/go-isatty v0.0.22 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasttemplate v1.2.2 // indirect
+	golang.org/x/crypto v0.55.0 // indirect
+	golang.org/x/net v0.58.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.41.0 // indirect
+)
+
+replace github.com/ant1441/logger-logrus => ../
@@ -0,0 +1,98 @@
+// Package fasthttplogger adapts the logger middleware to fasthttp, so that fasthttp services log requests with the same Options and fields as net/http ones.
+package fasthttplogger
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/ant1441/logger-logrus"
+	"github.com/valyala/fasthttp"
+)
+
+// FastHTTP returns a fasthttp request handler serving requests with next, and logging them as logger.New(opts...) does.
+func FastHTTP(next fasthttp.RequestHandler, opts ...logger.Options) fasthttp.RequestHandler {
+	return Middleware(logger.New(opts...))(next)
+}
+
+// Middleware returns a fasthttp middleware logging requests with l, so that it can be shared with net/http handlers or reconfigured with SetOptions. The middleware sees the request as a net/http request without body, whose Content-Length is the one declared, and the response once next returns: its status, headers and body size, unless it is streamed.
+func Middleware(l *logger.Logger) func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		h := l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(ctxKey).(*fasthttp.RequestCtx)
+			next(ctx)
+			writeResponse(w, &ctx.Response)
+		}))
+		return func(ctx *fasthttp.RequestCtx) {
+			r, err := newRequest(ctx)
+			if err != nil {
+				next(ctx)
+				return
+			}
+			h.ServeHTTP(&responseWriter{header: http.Header{}}, r)
+		}
+	}
+}
+
+type contextKey int
+
+// ctxKey is the context key of the fasthttp request served.
+const ctxKey contextKey = 0
+
+// newRequest returns the net/http request of ctx, carrying ctx in its context. Its fields are copies, as entries may be written once ctx is reused, under AsyncQueueSize.
+func newRequest(ctx *fasthttp.RequestCtx) (*http.Request, error) {
+	uri := string(ctx.RequestURI())
+	u, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	r := &http.Request{
+		Method:     string(ctx.Method()),
+		URL:        u,
+		Proto:      string(ctx.Request.Header.Protocol()),
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       http.NoBody,
+		Host:       string(ctx.Host()),
+		RemoteAddr: ctx.RemoteAddr().String(),
+		RequestURI: uri,
+		TLS:        ctx.TLSConnectionState(),
+	}
+	if n := ctx.Request.Header.ContentLength(); n > 0 {
+		r.ContentLength = int64(n)
+	}
+	for key, val := range ctx.Request.Header.All() {
+		r.Header.Add(string(key), string(val))
+	}
+	if major, minor, ok := http.ParseHTTPVersion(r.Proto); ok {
+		r.ProtoMajor, r.ProtoMinor = major, minor
+	}
+	return r.WithContext(context.WithValue(context.Background(), ctxKey, ctx)), nil
+}
+
+// writeResponse writes the status, headers and body of resp to w, for the middleware to log them.
+func writeResponse(w http.ResponseWriter, resp *fasthttp.Response) {
+	for key, val := range resp.Header.All() {
+		w.Header().Add(string(key), string(val))
+	}
+	w.WriteHeader(resp.StatusCode())
+	if !resp.IsBodyStream() {
+		w.Write(resp.Body())
+	}
+}
+
+// responseWriter discards the response written by the middleware, which fasthttp writes itself.
+type responseWriter struct {
+	header http.Header
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *responseWriter) WriteHeader(status int) {}
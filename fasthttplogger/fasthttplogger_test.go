@@ -0,0 +1,61 @@
+package fasthttplogger
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/ant1441/logger-logrus"
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+)
+
+func serve(h fasthttp.RequestHandler, method, uri string, header map[string]string) *fasthttp.RequestCtx {
+	var ctx fasthttp.RequestCtx
+	var req fasthttp.Request
+	req.Header.SetMethod(method)
+	req.SetRequestURI(uri)
+	req.Header.SetHost("example.com")
+	for key, val := range header {
+		req.Header.Set(key, val)
+	}
+	ctx.Init(&req, &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}, nil)
+	h(&ctx)
+	return &ctx
+}
+
+func TestFastHTTP(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	log := logrus.New()
+	log.SetOutput(buf)
+
+	proxies, _ := logger.ParseCIDRs("10.0.0.0/8")
+	h := FastHTTP(func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusCreated)
+		ctx.Response.Header.Set("Cache-Control", "no-store")
+		ctx.WriteString("bar")
+	}, logger.Options{
+		Logger:               log,
+		RemoteAddressHeaders: []string{"X-Forwarded-For"},
+		TrustedProxies:       proxies,
+		ResponseHeaders:      []string{"Cache-Control"},
+		CustomFields:         logrus.Fields{"tier": "edge"},
+		IgnoredRequestURIs:   []string{"/health"},
+	})
+
+	ctx := serve(h, "GET", "/foo?q=1", map[string]string{"X-Forwarded-For": "203.0.113.7"})
+	if ctx.Response.StatusCode() != fasthttp.StatusCreated || string(ctx.Response.Body()) != "bar" {
+		t.Fatalf("got %d %q", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	serve(h, "GET", "/health", nil)
+
+	for _, want := range []string{"http_addr=203.0.113.7", "http_method=GET", "http_uri=\"/foo?q=1\"", "http_host=example.com", "http_status=201", "http_size=3", "http_resp_cache_control=no-store", "tier=edge"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%q not found in %q", want, buf.String())
+		}
+	}
+	if strings.Count(buf.String(), "Request received") != 1 {
+		t.Errorf("expected 1 entry in %q", buf.String())
+	}
+}
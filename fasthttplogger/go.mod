@@ -0,0 +1,18 @@
+module github.com/ant1441/logger-logrus/fasthttplogger
+
+go 1.25.0
+
+require (
+	github.com/ant1441/logger-logrus v0.0.0
+	github.com/sirupsen/logrus v1.10.2
+	github.com/valyala/fasthttp v1.74.0
+)
+
+require (
+	github.com/klauspost/compress v1.20.0 // indirect
+	github.com/molecule-man/go-brrr v1.0.1 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+)
+
+replace github.com/ant1441/logger-logrus => ../
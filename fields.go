@@ -0,0 +1,164 @@
+package logger
+
+import "github.com/sirupsen/logrus"
+
+// Field keys of the standard fields logged by the middleware.
+const (
+	FieldAddr     = "http_addr"
+	FieldMethod   = "http_method"
+	FieldURI      = "http_uri"
+	FieldProto    = "http_proto"
+	FieldStatus   = "http_status"
+	FieldSize     = "http_size"
+	FieldDuration = "http_duration"
+	FieldTTFB     = "http_ttfb"
+	FieldHost     = "http_host"
+	FieldScheme   = "http_scheme"
+	FieldPath     = "http_path"
+	FieldQuery    = "http_query"
+)
+
+// Field keys of the optional fields logged by the middleware.
+const (
+	FieldRequestID            = "http_request_id"
+	FieldReplayOf             = "replay_of"
+	FieldReplaySeq            = "replay_seq"
+	FieldEarlyHints           = "http_early_hints"
+	FieldEarlyHintsTime       = "http_early_hints_time"
+	FieldInformational        = "http_informational"
+	FieldError                = "http_error"
+	FieldPanic                = "http_panic"
+	FieldErrorBody            = "http_error_body"
+	FieldProbePath            = "probe_path"
+	FieldProbeLastSeen        = "probe_last_seen"
+	FieldTraceID              = "trace_id"
+	FieldSpanID               = "span_id"
+	FieldTraceSampled         = "trace_sampled"
+	FieldProxyUpstreamTime    = "proxy_upstream_time_ms"
+	FieldProxyQueueTime       = "proxy_queue_time_ms"
+	FieldCDNCacheStatus       = "cdn_cache_status"
+	FieldRetryAfter           = "http_retry_after"
+	FieldRateLimitLimit       = "http_ratelimit_limit"
+	FieldRateLimitRemaining   = "http_ratelimit_remaining"
+	FieldRateLimitReset       = "http_ratelimit_reset"
+	FieldArrivalRate          = "http_arrival_rate"
+	FieldPort                 = "http_port"
+	FieldProtoVersion         = "http_version"
+	FieldTrafficOrigin        = "traffic_origin"
+	FieldExpectContinue       = "http_expect_continue"
+	FieldContinueSent         = "http_continue_sent"
+	FieldRequestLength        = "http_request_length"
+	FieldRequestSize          = "http_request_size"
+	FieldRequestReadTime      = "http_request_read_time"
+	FieldRequestConsumed      = "http_request_consumed"
+	FieldContinueWait         = "http_continue_wait"
+	FieldReferer              = "http_referer"
+	FieldUserAgent            = "http_user_agent"
+	FieldUser                 = "http_user"
+	FieldClientID             = "http_client_id"
+	FieldAPIKeyFingerprint    = "http_api_key_fingerprint"
+	FieldBotSignatureAgent    = "bot_signature_agent"
+	FieldBotSignatureKeyID    = "bot_signature_keyid"
+	FieldBotSignatureTag      = "bot_signature_tag"
+	FieldBotSignatureExpired  = "bot_signature_expired"
+	FieldBotVerified          = "bot_verified"
+	FieldBotScore             = "bot_score"
+	FieldBotFrom              = "bot_from"
+	FieldUABrowser            = "ua_browser"
+	FieldUAOS                 = "ua_os"
+	FieldUAIsBot              = "ua_is_bot"
+	FieldGeoCountry           = "geo_country"
+	FieldGeoASN               = "geo_asn"
+	FieldClientHost           = "http_client_host"
+	FieldHostname             = "hostname"
+	FieldPID                  = "pid"
+	FieldServiceName          = "service_name"
+	FieldServiceEnvironment   = "service_environment"
+	FieldServiceVersion       = "service_version"
+	FieldGitSHA               = "git_sha"
+	FieldRoute                = "http_route"
+	FieldHandler              = "http_handler"
+	FieldDurationSeconds      = "http_duration_seconds"
+	FieldSlow                 = "slow"
+	FieldRateLimitKey         = "rate_limit_key"
+	FieldRateLimitSuppressed  = "rate_limit_suppressed"
+	FieldDebug                = "debug"
+	FieldResponseBody         = "http_response_body"
+	FieldTLSVersion           = "tls_version"
+	FieldTLSCipher            = "tls_cipher"
+	FieldTLSServerName        = "tls_server_name"
+	FieldTLSProtocol          = "tls_protocol"
+	FieldClientDisconnected   = "http_client_disconnected"
+	FieldCancelCause          = "http_cancel_cause"
+	FieldTimedOut             = "http_timed_out"
+	FieldTimeout              = "http_timeout"
+	FieldUpstream             = "http_upstream"
+	FieldUpstreamDuration     = "http_upstream_duration"
+	FieldUpstreamAttempts     = "http_upstream_attempts"
+	FieldProxyError           = "http_proxy_error"
+	FieldContentType          = "http_content_type"
+	FieldContentEncoding      = "http_content_encoding"
+	FieldLengthMismatch       = "http_length_mismatch"
+	FieldContentLength        = "http_content_length"
+	FieldDuplicateWriteHeader = "http_duplicate_writeheader"
+	FieldDuplicateStatus      = "http_duplicate_status"
+	FieldStream               = "http_stream"
+	FieldStreamFlushes        = "http_stream_flushes"
+	FieldStreamDuration       = "http_stream_duration"
+	FieldCloudHTTPRequest     = "httpRequest"
+	FieldCloudSeverity        = "severity"
+	FieldCloudTrace           = "logging.googleapis.com/trace"
+	FieldCloudSpanID          = "logging.googleapis.com/spanId"
+	FieldCloudTraceSampled    = "logging.googleapis.com/trace_sampled"
+)
+
+// ECSFieldNames maps the keys of the fields logged by the middleware to their Elastic Common Schema counterparts, for use as Options.FieldNames. ECS expects `event.duration` in nanoseconds, and `client.ip` as a bare IP address: see the "ecs" preset.
+var ECSFieldNames = map[string]string{
+	FieldAddr:               "client.ip",
+	FieldPort:               "client.port",
+	FieldMethod:             "http.request.method",
+	FieldURI:                "url.original",
+	FieldStatus:             "http.response.status_code",
+	FieldSize:               "http.response.body.bytes",
+	FieldRequestSize:        "http.request.body.bytes",
+	FieldContentType:        "http.response.mime_type",
+	FieldDuration:           "event.duration",
+	FieldHost:               "url.domain",
+	FieldScheme:             "url.scheme",
+	FieldPath:               "url.path",
+	FieldQuery:              "url.query",
+	FieldRequestID:          "http.request.id",
+	FieldReferer:            "http.request.referrer",
+	FieldUserAgent:          "user_agent.original",
+	FieldUser:               "user.name",
+	FieldTraceID:            "trace.id",
+	FieldSpanID:             "span.id",
+	FieldTLSVersion:         "tls.version",
+	FieldTLSCipher:          "tls.cipher",
+	FieldTLSServerName:      "tls.client.server_name",
+	FieldTLSProtocol:        "tls.next_protocol",
+	FieldProtoVersion:       "http.version",
+	FieldHostname:           "host.hostname",
+	FieldPID:                "process.pid",
+	FieldServiceName:        "service.name",
+	FieldServiceEnvironment: "service.environment",
+	FieldServiceVersion:     "service.version",
+}
+
+// renameFields renames the fields whose keys are in names to the keys they map to.
+func renameFields(fields logrus.Fields, names map[string]string) {
+	if len(names) == 0 {
+		return
+	}
+	// Values are moved once all of them are taken out, so that keys may be swapped.
+	moved := make(map[string]interface{}, len(names))
+	for key, name := range names {
+		if val, ok := fields[key]; ok && key != name {
+			moved[name] = val
+			delete(fields, key)
+		}
+	}
+	for name, val := range moved {
+		fields[name] = val
+	}
+}
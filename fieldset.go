@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FieldSet selects the set of fields logged for each request.
+type FieldSet int
+
+const (
+	// FieldSetStandard logs the standard fields.
+	FieldSetStandard FieldSet = iota
+	// FieldSetMinimal logs `http_method`, `http_path`, `http_status` and `http_duration` only, of the standard fields.
+	FieldSetMinimal
+	// FieldSetVerbose logs the standard fields, the referer, the user agent, every request and response header, redacted, and the TLS connection state.
+	FieldSetVerbose
+)
+
+// StandardFields is a set of standard fields, as a bitmask of the Omit constants.
+type StandardFields uint
+
+// Standard fields, to be combined in Options.OmittedFields, e.g. `OmitAddr | OmitProto`.
+const (
+	OmitAddr StandardFields = 1 << iota
+	OmitMethod
+	OmitURI
+	OmitProto
+	OmitStatus
+	OmitSize
+	OmitDuration
+	OmitTTFB
+	OmitHost
+	OmitScheme
+	OmitPath
+	OmitQuery
+
+	allStandardFields = OmitQuery<<1 - 1
+)
+
+// standardFieldKeys are the keys of the standard fields, in the order of the Omit constants.
+var standardFieldKeys = []string{FieldAddr, FieldMethod, FieldURI, FieldProto, FieldStatus, FieldSize, FieldDuration, FieldTTFB, FieldHost, FieldScheme, FieldPath, FieldQuery}
+
+// minimalOmitted are the standard fields not part of FieldSetMinimal.
+const minimalOmitted = OmitAddr | OmitURI | OmitProto | OmitSize | OmitTTFB | OmitHost | OmitScheme | OmitQuery
+
+// omitFields removes the standard fields in omitted from fields.
+func omitFields(fields logrus.Fields, omitted StandardFields) {
+	for i, key := range standardFieldKeys {
+		if omitted&(1<<uint(i)) != 0 {
+			delete(fields, key)
+		}
+	}
+}
+
+// minimalFields removes the standard fields not part of FieldSetMinimal from fields, and logs the request path as `http_path` whatever URIFields.
+func minimalFields(fields logrus.Fields, uri string) {
+	omitFields(fields, minimalOmitted)
+	fields[FieldPath], _ = splitURI(uri)
+}
+
+// verboseFields adds the fields of FieldSetVerbose not logged otherwise to fields: every request and response header, redacted, and the TLS connection state of r.
+func (l *Logger) verboseFields(fields logrus.Fields, r *http.Request, crw *customResponseWriter) {
+	l.allHeaderFields(fields, r, crw)
+	if r.TLS != nil {
+		fields[FieldTLSVersion] = tlsVersionName(r.TLS.Version)
+		fields[FieldTLSCipher] = cipherSuiteName(r.TLS.CipherSuite)
+		if len(r.TLS.ServerName) > 0 {
+			fields[FieldTLSServerName] = r.TLS.ServerName
+		}
+		if len(r.TLS.NegotiatedProtocol) > 0 {
+			fields[FieldTLSProtocol] = r.TLS.NegotiatedProtocol
+		}
+	}
+}
+
+// tlsVersionName returns the name of the TLS version v, e.g. "TLS 1.3".
+func tlsVersionName(v uint16) string {
+	switch v {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	}
+	return fmt.Sprintf("0x%04X", v)
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFieldSetMinimal(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:   logger,
+		FieldSet: FieldSetMinimal,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo?bar=baz", nil)
+	req.RequestURI = "/foo?bar=baz"
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_method=GET")
+	expectContainsTrue(t, buf.String(), "http_path=/foo")
+	expectContainsTrue(t, buf.String(), "http_status=200")
+	expectContainsTrue(t, buf.String(), "http_duration=")
+	expectContainsFalse(t, buf.String(), "http_addr")
+	expectContainsFalse(t, buf.String(), "http_uri")
+	expectContainsFalse(t, buf.String(), "http_query")
+	expectContainsFalse(t, buf.String(), "http_size")
+	expectContainsFalse(t, buf.String(), "http_ttfb")
+}
+
+func TestFieldSetVerbose(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:   logger,
+		FieldSet: FieldSetVerbose,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set("User-Agent", "curl/7.64.1")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.TLS = &tls.ConnectionState{
+		Version:     tls.VersionTLS13,
+		CipherSuite: tls.TLS_AES_128_GCM_SHA256,
+		ServerName:  "example.com",
+	}
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write([]byte("bar"))
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_addr=")
+	expectContainsTrue(t, buf.String(), "http_referer=\"https://example.com/\"")
+	expectContainsTrue(t, buf.String(), "http_user_agent=curl/7.64.1")
+	expectContainsTrue(t, buf.String(), "http_req_authorization=REDACTED")
+	expectContainsTrue(t, buf.String(), "http_resp_cache_control=no-store")
+	expectContainsTrue(t, buf.String(), "tls_version=\"TLS 1.3\"")
+	expectContainsTrue(t, buf.String(), "tls_cipher="+cipherSuiteName(tls.TLS_AES_128_GCM_SHA256))
+	expectContainsTrue(t, buf.String(), "tls_server_name=example.com")
+	expectContainsFalse(t, buf.String(), "debug=true")
+}
+
+func TestTLSVersionName(t *testing.T) {
+	expect(t, tlsVersionName(tls.VersionTLS12), "TLS 1.2")
+	expect(t, tlsVersionName(0x0300), "0x0300")
+}
+
+func TestOmittedFields(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:        logger,
+		OmittedFields: OmitAddr | OmitProto,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), "http_addr")
+	expectContainsFalse(t, buf.String(), "http_proto")
+	expectContainsTrue(t, buf.String(), "http_method=GET")
+	expectContainsTrue(t, buf.String(), "http_status=200")
+	expect(t, len(standardFieldKeys), 12)
+}
@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"net"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GeoLocation is the location of a client IP address, logged as `geo_country` and `geo_asn`.
+type GeoLocation struct {
+	// Country is the ISO 3166-1 alpha-2 code of the country, e.g. "FR", or empty if unknown.
+	Country string
+	// ASN is the number of the autonomous system announcing the address, or 0 if unknown.
+	ASN uint
+}
+
+// GeoResolver locates client IP addresses, e.g. backed by a MaxMind database or an internal service.
+type GeoResolver interface {
+	// Resolve returns the location of ip. Locations are cached, unless an error is returned, in which case no location is logged.
+	Resolve(ip net.IP) (GeoLocation, error)
+}
+
+// geoCache caches the locations returned by a GeoResolver, keyed by IP address.
+type geoCache struct {
+	resolver GeoResolver
+	mu       sync.Mutex
+	seen     *lruCache
+}
+
+func newGeoCache(resolver GeoResolver, size int) *geoCache {
+	return &geoCache{resolver: resolver, seen: newLRUCache(size)}
+}
+
+// resolve returns the location of ip, from the cache if it was resolved before.
+func (c *geoCache) resolve(ip net.IP) (GeoLocation, bool) {
+	key := ip.String()
+	c.mu.Lock()
+	v, ok := c.seen.get(key)
+	c.mu.Unlock()
+	if ok {
+		return v.(GeoLocation), true
+	}
+
+	// The resolver is called outside of the lock, so that a slow lookup does not hold up other requests.
+	loc, err := c.resolver.Resolve(ip)
+	if err != nil {
+		return GeoLocation{}, false
+	}
+	c.mu.Lock()
+	c.seen.add(key, loc)
+	c.mu.Unlock()
+	return loc, true
+}
+
+// geoFields adds the location of the client address ip to fields, if it is known.
+func (l *Logger) geoFields(fields logrus.Fields, ip net.IP) {
+	loc, ok := l.geo.resolve(ip)
+	if !ok {
+		return
+	}
+	if len(loc.Country) > 0 {
+		fields[FieldGeoCountry] = loc.Country
+	}
+	if loc.ASN > 0 {
+		fields[FieldGeoASN] = loc.ASN
+	}
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// countingGeoResolver locates 203.0.113.7 in France and fails for any other address, counting its lookups.
+type countingGeoResolver struct {
+	lookups int
+}
+
+func (g *countingGeoResolver) Resolve(ip net.IP) (GeoLocation, error) {
+	g.lookups++
+	if !ip.Equal(net.ParseIP("203.0.113.7")) {
+		return GeoLocation{}, fmt.Errorf("unknown address %v", ip)
+	}
+	return GeoLocation{Country: "FR", ASN: 64496}, nil
+}
+
+func TestGeoResolver(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	resolver := &countingGeoResolver{}
+	l := New(Options{
+		Logger:      logger,
+		GeoResolver: resolver,
+	})
+	expect(t, l.opt.GeoCacheSize, 10000)
+
+	for i := 0; i < 2; i++ {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		l.Handler(myHandler).ServeHTTP(res, req)
+	}
+
+	expectContainsTrue(t, buf.String(), "geo_country=FR")
+	expectContainsTrue(t, buf.String(), "geo_asn=64496")
+	expect(t, resolver.lookups, 1)
+
+	buf.Reset()
+	for i := 0; i < 2; i++ {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		req.RemoteAddr = "198.51.100.1:1234"
+		l.Handler(myHandler).ServeHTTP(res, req)
+	}
+
+	expectContainsFalse(t, buf.String(), "geo_country")
+	expectContainsFalse(t, buf.String(), "geo_asn")
+	expect(t, resolver.lookups, 3)
+
+	// The resolver and its cache are kept by SetOptions.
+	l.SetOptions(Options{
+		Logger:  logger,
+		Message: "Served",
+	})
+	buf.Reset()
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "203.0.113.7:1234"
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "geo_country=FR")
+	expect(t, resolver.lookups, 3)
+	expect(t, l.Options().GeoCacheSize, 10000)
+}
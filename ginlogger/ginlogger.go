@@ -0,0 +1,82 @@
+// Package ginlogger adapts the logger middleware to gin, so that gin services log requests with the same Options and fields as net/http ones.
+package ginlogger
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/ant1441/logger-logrus"
+	"github.com/gin-gonic/gin"
+)
+
+// Gin returns a gin middleware logging requests as logger.New(opts...) does, with the gin route path, e.g. "/users/:id", as `http_route`.
+func Gin(opts ...logger.Options) gin.HandlerFunc {
+	return Middleware(logger.New(opts...))
+}
+
+// Middleware returns a gin middleware logging requests with l, so that it can be shared with net/http handlers or reconfigured with SetOptions.
+func Middleware(l *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gw := c.Writer
+			defer func() { c.Writer = gw }()
+
+			c.Request = r
+			c.Writer = &responseWriter{ResponseWriter: gw, w: w}
+			if route := c.FullPath(); len(route) > 0 {
+				logger.SetRoute(r.Context(), route)
+			}
+			c.Next()
+
+			// gin writes the status of unrouted requests after the middlewares return, e.g. 404, so it is recorded now.
+			if !gw.Written() {
+				w.WriteHeader(gw.Status())
+			}
+		})).ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// responseWriter is the gin.ResponseWriter of the handlers after the middleware, writing the response through the writer of the middleware w, which itself writes to the gin.ResponseWriter it wraps.
+type responseWriter struct {
+	gin.ResponseWriter
+	w http.ResponseWriter
+}
+
+func (rw *responseWriter) Header() http.Header {
+	return rw.w.Header()
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	rw.w.WriteHeader(code)
+}
+
+// WriteHeaderNow writes the pending status, e.g. set with c.Status, through the middleware writer first.
+func (rw *responseWriter) WriteHeaderNow() {
+	if !rw.Written() {
+		rw.w.WriteHeader(rw.Status())
+	}
+	rw.ResponseWriter.WriteHeaderNow()
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	return rw.w.Write(b)
+}
+
+func (rw *responseWriter) WriteString(s string) (int, error) {
+	return io.WriteString(rw.w, s)
+}
+
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := rw.w.(http.Hijacker); ok {
+		return hj.Hijack()
+	}
+	return rw.ResponseWriter.Hijack()
+}
@@ -0,0 +1,71 @@
+package ginlogger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ant1441/logger-logrus"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func serve(engine *gin.Engine, method, uri string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, uri, nil)
+	req.RequestURI = uri
+	engine.ServeHTTP(res, req)
+	return res
+}
+
+func TestGin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	buf := bytes.NewBufferString("")
+	log := logrus.New()
+	log.SetOutput(buf)
+
+	engine := gin.New()
+	engine.Use(Gin(logger.Options{Logger: log}))
+	engine.GET("/users/:id", func(c *gin.Context) {
+		c.String(http.StatusCreated, "user %s", c.Param("id"))
+	})
+
+	res := serve(engine, "GET", "/users/42")
+	if res.Code != http.StatusCreated || res.Body.String() != "user 42" {
+		t.Fatalf("got %d %q", res.Code, res.Body.String())
+	}
+	for _, want := range []string{"http_route=\"/users/:id\"", "http_uri=/users/42", "http_status=201", "http_size=7"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%q not found in %q", want, buf.String())
+		}
+	}
+}
+
+func TestGinStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	buf := bytes.NewBufferString("")
+	log := logrus.New()
+	log.SetOutput(buf)
+
+	engine := gin.New()
+	engine.Use(Middleware(logger.New(logger.Options{Logger: log})))
+	engine.DELETE("/users/:id", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	res := serve(engine, "DELETE", "/users/42")
+	if res.Code != http.StatusNoContent {
+		t.Fatalf("got %d", res.Code)
+	}
+	res = serve(engine, "GET", "/missing")
+	if res.Code != http.StatusNotFound || res.Body.String() != "404 page not found" {
+		t.Fatalf("got %d %q", res.Code, res.Body.String())
+	}
+	for _, want := range []string{"http_status=204", "http_route=\"/users/:id\"", "http_status=404"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%q not found in %q", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,5 @@
+module github.com/ant1441/logger-logrus
+
+go 1.13
+
+require github.com/sirupsen/logrus v1.9.3
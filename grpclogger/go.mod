@@ -0,0 +1,19 @@
+module github.com/ant1441/logger-logrus/grpclogger
+
+go 1.25.0
+
+require (
+	github.com/ant1441/logger-logrus v0.0.0
+	github.com/sirupsen/logrus v1.10.2
+	google.golang.org/grpc v1.84.0
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	golang.org/x/net v0.58.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.41.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260706201446-f0a921348800 // indirect
+)
+
+replace github.com/ant1441/logger-logrus => ../
@@ -0,0 +1,139 @@
+// Package grpclogger provides gRPC server interceptors logging calls with the Options and conventions of the logger middleware, so that HTTP and gRPC services in one binary share a log shape.
+package grpclogger
+
+import (
+	"context"
+	"time"
+
+	"github.com/ant1441/logger-logrus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Field keys of the gRPC calls, logged along with the `http_addr` and `http_duration` fields of the middleware.
+const (
+	FieldMethod           = "grpc_method"
+	FieldCode             = "grpc_code"
+	FieldRequestSize      = "grpc_request_size"
+	FieldResponseSize     = "grpc_response_size"
+	FieldMessagesReceived = "grpc_msgs_received"
+	FieldMessagesSent     = "grpc_msgs_sent"
+)
+
+// UnaryServerInterceptor returns an interceptor logging unary calls with l, along with the sizes of their request and response messages.
+func UnaryServerInterceptor(l *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, l, info.FullMethod, err, time.Since(start), func(fields logrus.Fields) {
+			if size, ok := messageSize(req); ok {
+				fields[FieldRequestSize] = size
+			}
+			if size, ok := messageSize(resp); ok && err == nil {
+				fields[FieldResponseSize] = size
+			}
+		})
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns an interceptor logging streaming calls with l, along with the number of messages received and sent, and their total sizes.
+func StreamServerInterceptor(l *logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		stream := &serverStream{ServerStream: ss}
+		err := handler(srv, stream)
+		logCall(ss.Context(), l, info.FullMethod, err, time.Since(start), func(fields logrus.Fields) {
+			fields[FieldMessagesReceived] = stream.received
+			fields[FieldMessagesSent] = stream.sent
+			fields[FieldRequestSize] = stream.receivedSize
+			fields[FieldResponseSize] = stream.sentSize
+		})
+		return err
+	}
+}
+
+// logCall logs the call to method from the peer of ctx, completed with err in duration, with the fields added by add, as Options say: IgnoredRequestURIs are matched against full method names, e.g. "/grpc.health.v1.Health/Check", ErrorsOnly drops successful calls, and LevelByStatus logs client errors at Warn level and server errors at Error level.
+func logCall(ctx context.Context, l *logger.Logger, method string, err error, duration time.Duration, add func(fields logrus.Fields)) {
+	o := l.Options()
+	for _, ignored := range o.IgnoredRequestURIs {
+		if ignored == method {
+			return
+		}
+	}
+	code := status.Code(err)
+	if o.ErrorsOnly && code == codes.OK {
+		return
+	}
+
+	fields := logrus.Fields{
+		FieldMethod:          method,
+		FieldCode:            code.String(),
+		logger.FieldDuration: o.DurationFormat.Format(duration),
+	}
+	if o.DurationSeconds {
+		fields[logger.FieldDurationSeconds] = duration.Seconds()
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields[logger.FieldAddr] = p.Addr.String()
+	}
+	add(fields)
+
+	level := logrus.InfoLevel
+	if o.LevelByStatus {
+		level = codeLevel(code)
+	}
+	l.Log(level, fields)
+}
+
+// codeLevel returns the level of calls completed with code under LevelByStatus: Error for server errors, Warn for client errors, and Info otherwise.
+func codeLevel(code codes.Code) logrus.Level {
+	switch code {
+	case codes.OK:
+		return logrus.InfoLevel
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return logrus.ErrorLevel
+	}
+	return logrus.WarnLevel
+}
+
+// messageSize returns the encoded size of m, if it is a protobuf message.
+func messageSize(m interface{}) (int, bool) {
+	if msg, ok := m.(proto.Message); ok {
+		return proto.Size(msg), true
+	}
+	return 0, false
+}
+
+// serverStream counts the messages received and sent on a stream, and their sizes.
+type serverStream struct {
+	grpc.ServerStream
+	received, sent         int
+	receivedSize, sentSize int
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received++
+		if size, ok := messageSize(m); ok {
+			s.receivedSize += size
+		}
+	}
+	return err
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent++
+		if size, ok := messageSize(m); ok {
+			s.sentSize += size
+		}
+	}
+	return err
+}
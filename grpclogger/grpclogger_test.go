@@ -0,0 +1,120 @@
+package grpclogger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/ant1441/logger-logrus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newLogger(o logger.Options) (*logger.Logger, *bytes.Buffer) {
+	buf := bytes.NewBufferString("")
+	log := logrus.New()
+	log.SetOutput(buf)
+	o.Logger = log
+	return logger.New(o), buf
+}
+
+func expectContains(t *testing.T, s string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(s, w) {
+			t.Errorf("%q not found in %q", w, s)
+		}
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	l, buf := newLogger(logger.Options{LevelByStatus: true, IgnoredRequestURIs: []string{"/grpc.health.v1.Health/Check"}})
+	interceptor := UnaryServerInterceptor(l)
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}})
+
+	resp, err := interceptor(ctx, wrapperspb.String("hello"), &grpc.UnaryServerInfo{FullMethod: "/pkg.Greeter/Greet"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return wrapperspb.String("hello, world"), nil
+	})
+	if err != nil || resp.(*wrapperspb.StringValue).GetValue() != "hello, world" {
+		t.Fatalf("got %v, %v", resp, err)
+	}
+	expectContains(t, buf.String(), "level=info", "grpc_method=/pkg.Greeter/Greet", "grpc_code=OK", "grpc_request_size=7", "grpc_response_size=14", "http_addr=\"10.0.0.1:1234\"", "http_duration=")
+
+	buf.Reset()
+	interceptor(ctx, wrapperspb.String("hello"), &grpc.UnaryServerInfo{FullMethod: "/pkg.Greeter/Greet"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "no such greeting")
+	})
+	expectContains(t, buf.String(), "level=warning", "grpc_code=NotFound")
+	if strings.Contains(buf.String(), "grpc_response_size") {
+		t.Errorf("unexpected response size in %q", buf.String())
+	}
+
+	buf.Reset()
+	interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	if buf.Len() > 0 {
+		t.Errorf("ignored call logged: %q", buf.String())
+	}
+}
+
+type testStream struct {
+	grpc.ServerStream
+	in []string
+}
+
+func (s *testStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *testStream) RecvMsg(m interface{}) error {
+	if len(s.in) == 0 {
+		return io.EOF
+	}
+	m.(*wrapperspb.StringValue).Value, s.in = s.in[0], s.in[1:]
+	return nil
+}
+
+func (s *testStream) SendMsg(m interface{}) error {
+	return nil
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	l, buf := newLogger(logger.Options{})
+	interceptor := StreamServerInterceptor(l)
+
+	err := interceptor(nil, &testStream{in: []string{"a", "bb"}}, &grpc.StreamServerInfo{FullMethod: "/pkg.Greeter/Chat"}, func(srv interface{}, ss grpc.ServerStream) error {
+		for {
+			var msg wrapperspb.StringValue
+			if err := ss.RecvMsg(&msg); err == io.EOF {
+				return nil
+			}
+			ss.SendMsg(wrapperspb.String(msg.GetValue() + "!"))
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectContains(t, buf.String(), "grpc_method=/pkg.Greeter/Chat", "grpc_code=OK", "grpc_msgs_received=2", "grpc_msgs_sent=2", "grpc_request_size=7", "grpc_response_size=9")
+}
+
+func TestCodeLevel(t *testing.T) {
+	for code, want := range map[codes.Code]logrus.Level{
+		codes.OK:              logrus.InfoLevel,
+		codes.InvalidArgument: logrus.WarnLevel,
+		codes.Canceled:        logrus.WarnLevel,
+		codes.Internal:        logrus.ErrorLevel,
+		codes.Unavailable:     logrus.ErrorLevel,
+	} {
+		if got := codeLevel(code); got != want {
+			t.Errorf("codeLevel(%v) = %v, want %v", code, got, want)
+		}
+	}
+}
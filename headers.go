@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted replaces the value of redacted headers and query parameters.
+const Redacted = "REDACTED"
+
+// DefaultRedactedHeaders are the headers whose values are always replaced by Redacted when logged.
+var DefaultRedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// headerRedactor redacts header values before they are logged.
+type headerRedactor struct {
+	redacted map[string]bool
+	custom   func(name, value string) string
+}
+
+func newHeaderRedactor(extra []string, custom func(name, value string) string) *headerRedactor {
+	redacted := make(map[string]bool, len(DefaultRedactedHeaders)+len(extra))
+	for _, name := range DefaultRedactedHeaders {
+		redacted[http.CanonicalHeaderKey(name)] = true
+	}
+	for _, name := range extra {
+		redacted[http.CanonicalHeaderKey(name)] = true
+	}
+	return &headerRedactor{redacted: redacted, custom: custom}
+}
+
+// redact returns the loggable form of the value of the canonical header name.
+func (h *headerRedactor) redact(name, value string) string {
+	if h.redacted[name] {
+		return Redacted
+	}
+	if h.custom != nil {
+		return h.custom(name, value)
+	}
+	return value
+}
+
+// headerField is a header to be logged, along with the field key it is logged under.
+type headerField struct {
+	name string
+	key  string
+}
+
+// newHeaderFields returns the header fields for names, keyed by prefix followed by the lower-cased, underscore separated header name, e.g. "X-Api-Version" becomes "<prefix>x_api_version".
+func newHeaderFields(prefix string, names []string) []headerField {
+	fields := make([]headerField, 0, len(names))
+	for _, name := range names {
+		fields = append(fields, headerField{
+			name: http.CanonicalHeaderKey(name),
+			key:  headerFieldKey(prefix, name),
+		})
+	}
+	return fields
+}
+
+// headerFieldKey returns the field key of the header name, prefix followed by the lower-cased, underscore separated name.
+func headerFieldKey(prefix, name string) string {
+	return prefix + strings.ToLower(strings.Replace(name, "-", "_", -1))
+}
+
+// headerValue returns all the values of the canonical header name, joined by commas.
+func headerValue(h http.Header, name string) string {
+	return strings.Join(h[name], ", ")
+}
+
+// snapshotHeader registers the canonical response header name to be snapshotted by the response writer.
+func (l *Logger) snapshotHeader(name string) {
+	if _, ok := l.snapshotIndex[name]; ok {
+		return
+	}
+	if l.snapshotIndex == nil {
+		l.snapshotIndex = make(map[string]int)
+	}
+	l.snapshotIndex[name] = len(l.snapshotHeaders)
+	l.snapshotHeaders = append(l.snapshotHeaders, name)
+}
+
+// responseHeader returns the snapshotted value of the canonical response header name, which must have been registered with snapshotHeader.
+func (l *Logger) responseHeader(crw *customResponseWriter, name string) string {
+	if i, ok := l.snapshotIndex[name]; ok && crw.headerValues != nil {
+		return crw.headerValues[i]
+	}
+	return ""
+}
+
+// allHeaderFields adds every request header of r and every response header snapshotted by crw to fields, redacted.
+func (l *Logger) allHeaderFields(fields logrus.Fields, r *http.Request, crw *customResponseWriter) {
+	for name := range r.Header {
+		fields[headerFieldKey(l.opt.RequestHeaderPrefix, name)] = l.redactor.redact(name, headerValue(r.Header, name))
+	}
+	for name := range crw.header {
+		fields[headerFieldKey(l.opt.ResponseHeaderPrefix, name)] = l.redactor.redact(name, headerValue(crw.header, name))
+	}
+}
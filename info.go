@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestInfo describes a request served by the middleware, as passed to Options.OnComplete.
+type RequestInfo struct {
+	// Start is the time the middleware received the request.
+	Start time.Time
+	// Method is the request method.
+	Method string
+	// URI is the request URI, with RedactedQueryParams redacted.
+	URI string
+	// Route is the route template of the request, as logged as `http_route`, if any.
+	Route string
+	// Proto is the protocol version of the request, e.g. "HTTP/1.1".
+	Proto string
+	// Host is the host the request was sent to.
+	Host string
+	// Scheme is the scheme of the request, "http" or "https".
+	Scheme string
+	// RemoteAddr is the address of the client, as logged as `http_addr`.
+	RemoteAddr string
+	// RequestID is the value of the RequestIDHeader request header, if any, redacted as it is logged as `http_request_id`.
+	RequestID string
+	// Status is the response status code.
+	Status int
+	// Size is the number of bytes of the response body.
+	Size int
+	// Duration is the time it took to serve the request.
+	Duration time.Duration
+	// TTFB is the time it took to write the response headers.
+	TTFB time.Duration
+}
+
+// requestInfo returns the RequestInfo of r, served by crw from start in duration.
+func (l *Logger) requestInfo(r *http.Request, crw *customResponseWriter, start time.Time, duration time.Duration) RequestInfo {
+	addr, _ := l.remoteAddr(r)
+	info := RequestInfo{
+		Start:      start,
+		Method:     r.Method,
+		URI:        redactQuery(r.RequestURI, l.redactedParams),
+		Route:      l.route(r),
+		Proto:      r.Proto,
+		Host:       r.Host,
+		Scheme:     l.scheme(r),
+		RemoteAddr: addr,
+		Status:     crw.status,
+		Size:       crw.size,
+		Duration:   duration,
+		TTFB:       crw.ttfb,
+	}
+	if len(l.opt.RequestIDHeader) > 0 {
+		if id := r.Header.Get(l.opt.RequestIDHeader); len(id) > 0 {
+			info.RequestID = l.redactor.redact(http.CanonicalHeaderKey(l.opt.RequestIDHeader), id)
+		}
+	}
+	return info
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestOnComplete(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	var infos []RequestInfo
+	l := New(Options{
+		Logger:              logger,
+		IgnoredRequestURIs:  []string{"/healthz"},
+		RedactedQueryParams: []string{"token"},
+		RequestIDHeader:     "X-Request-ID",
+		OnComplete: func(info RequestInfo) {
+			infos = append(infos, info)
+		},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/foo?token=secret", nil)
+	req.RequestURI = "/foo?token=secret"
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Request-ID", "abc123")
+	l.Handler(myHandlerWithError).ServeHTTP(res, req)
+
+	req, _ = http.NewRequest("GET", "/healthz", nil)
+	req.RequestURI = "/healthz"
+	l.Handler(myHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	expect(t, len(infos), 2)
+	expect(t, infos[0].Method, "POST")
+	expect(t, infos[0].URI, "/foo?token=REDACTED")
+	expect(t, infos[0].Status, http.StatusBadGateway)
+	expect(t, infos[0].RemoteAddr, "10.0.0.1:1234")
+	expect(t, infos[0].RequestID, "abc123")
+	expect(t, infos[0].Duration > 0, true)
+	expect(t, infos[1].URI, "/healthz")
+	expect(t, infos[1].Size, 3)
+	expect(t, bytes.Count(buf.Bytes(), []byte("\n")), 1)
+}
+
+func TestOnCompleteRedactedRequestID(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+
+	var info RequestInfo
+	l := New(Options{
+		Logger:          logger,
+		RequestIDHeader: "X-Request-ID",
+		RedactedHeaders: []string{"X-Request-ID"},
+		OnComplete: func(i RequestInfo) {
+			info = i
+		},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	l.Handler(myHandler).ServeHTTP(httptest.NewRecorder(), req)
+	expect(t, info.RequestID, "REDACTED")
+
+	// HeaderRedactor applies as it does to `http_request_id`.
+	l = New(Options{
+		Logger:          logger,
+		RequestIDHeader: "X-Request-ID",
+		HeaderRedactor: func(name, value string) string {
+			return name + ":" + value[:3]
+		},
+		OnComplete: func(i RequestInfo) {
+			info = i
+		},
+	})
+	l.Handler(myHandler).ServeHTTP(httptest.NewRecorder(), req)
+	expect(t, info.RequestID, "X-Request-Id:abc")
+}
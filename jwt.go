@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// bearerToken returns the Bearer token of the Authorization header of r, if any.
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(auth[7:])
+}
+
+// parseJWTClaims returns the claims of the JWT token, decoded without being verified.
+func parseJWTClaims(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("logger: malformed JWT, expected 3 parts, got %d", len(parts))
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, err
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// jwtFields adds the JWTClaims of the Bearer token of r to fields, once verified by JWTVerifier if set.
+func (l *Logger) jwtFields(fields logrus.Fields, r *http.Request) {
+	token := bearerToken(r)
+	if len(token) == 0 {
+		return
+	}
+	parse := parseJWTClaims
+	if l.opt.JWTVerifier != nil {
+		parse = l.opt.JWTVerifier
+	}
+	claims, err := parse(token)
+	if err != nil {
+		return
+	}
+	for claim, key := range l.opt.JWTClaims {
+		if val, ok := claims[claim]; ok && val != nil {
+			fields[key] = val
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func testJWT(payload string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+}
+
+func TestParseJWTClaims(t *testing.T) {
+	claims, err := parseJWTClaims(testJWT(`{"sub":"alice","exp":1700000000}`))
+	expect(t, err, nil)
+	expect(t, claims["sub"], "alice")
+	expect(t, claims["exp"], float64(1700000000))
+
+	_, err = parseJWTClaims("not-a-jwt")
+	expect(t, err != nil, true)
+}
+
+func TestJWTClaims(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:    logger,
+		JWTClaims: map[string]string{"sub": FieldUser, "azp": FieldClientID},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Authorization", "Bearer "+testJWT(`{"sub":"alice","azp":"web-app","email":"alice@example.com"}`))
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_user=alice")
+	expectContainsTrue(t, buf.String(), "http_client_id=web-app")
+	expectContainsFalse(t, buf.String(), "alice@example.com")
+	expectContainsFalse(t, buf.String(), "Bearer")
+}
+
+func TestJWTVerifier(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:    logger,
+		JWTClaims: map[string]string{"sub": FieldUser},
+		JWTVerifier: func(token string) (map[string]interface{}, error) {
+			return nil, fmt.Errorf("invalid signature")
+		},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Authorization", "Bearer "+testJWT(`{"sub":"alice"}`))
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), "http_user")
+}
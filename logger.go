@@ -1,10 +1,18 @@
 package logger
 
 import (
-	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
 	"net"
 	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -14,28 +22,311 @@ import (
 type Options struct {
 	// Message is the outputted log message, default is "Request received"
 	Message string
+	// StatusMessages maps status classes, e.g. 5 for 5xx responses, to the message logged for their responses instead of Message, e.g. `map[int]string{4: "Client error", 5: "Request failed"}`. Default is nil.
+	StatusMessages map[int]string
+	// LogRequestStart also logs an entry with StartMessage when a request arrives, with its method, URI, address and request ID, so that long-running requests can be seen in progress. Default is false.
+	LogRequestStart bool
+	// StartMessage is the message of the entries logged by LogRequestStart. Default is "Request started".
+	StartMessage string
 	// CustomFields allows passing of custom logging fields
 	CustomFields logrus.Fields
+	// FieldsFunc is called with every logged request, its response status and size, and its duration, and returns the fields computed for it, e.g. a user ID from its context, which are added to its entry. Context values set by the handler on a derived request are not visible to it. Default is nil.
+	FieldsFunc func(r *http.Request, status, size int, d time.Duration) logrus.Fields
+	// ProcessFields logs the host name and the PID of the process in every entry, as `hostname` and `pid`. Default is false.
+	ProcessFields bool
+	// ServiceName is the name of the service logged in every entry as `service_name`. Default is empty, and thus not logged.
+	ServiceName string
+	// ServiceEnvironment is the deployment environment of the service logged in every entry as `service_environment`, e.g. "production". Default is empty, and thus not logged.
+	ServiceEnvironment string
+	// BuildInfo logs the version of the main module of the binary and the VCS revision it was built from, as read from debug.ReadBuildInfo, in every entry as `service_version` and `git_sha`, the latter for binaries built with Go 1.18 or later. Default is false.
+	BuildInfo bool
+	// OnComplete is called with the RequestInfo of every request served, whether it is logged or not, e.g. to record metrics. Default is nil.
+	OnComplete func(info RequestInfo)
+	// OnLog is called with every request entry, and the request it is about, just before the entry is written, so that it can enrich it, redact or drop fields, or change its level or message. The entry must not be retained once OnLog returns. Default is nil.
+	OnLog func(entry *logrus.Entry, r *http.Request)
+	// FieldNames maps the keys of the fields logged by the middleware, such as FieldStatus, to the keys they are logged under instead, e.g. `map[string]string{logger.FieldStatus: "status", logger.FieldURI: "url.path"}`. Default is nil, and thus the `http_` prefixed keys are logged.
+	FieldNames map[string]string
 	// RemoteAddressHeaders is a list of header keys that Logger will look at to determine the proper remote address. Useful when using a proxy like Nginx: `[]string{"X-Forwarded-Proto"}`. Default is an empty slice, and thus will use `reqeust.RemoteAddr`.
 	RemoteAddressHeaders []string
+	// SchemeHeader is the header key, such as "X-Forwarded-Proto", holding the scheme the client used, logged as `http_scheme`. Like RemoteAddressHeaders, it is only honored on requests from TrustedProxies. Default is empty, and thus the scheme is "https" for TLS requests and "http" otherwise.
+	SchemeHeader string
+	// ForwardedStrategy selects which address of a comma separated chain found in RemoteAddressHeaders, such as X-Forwarded-For, is logged: ForwardedRaw, ForwardedLeftmost or ForwardedRightmostUntrusted. Default is ForwardedRaw, and thus the whole header value is logged.
+	ForwardedStrategy ForwardedStrategy
+	// TrustedProxies is a list of networks whose requests are trusted to carry genuine RemoteAddressHeaders. Headers sent by any other peer are ignored, so clients cannot spoof the logged address. See ParseCIDRs. Default is an empty slice, and thus every peer is trusted.
+	TrustedProxies []net.IPNet
+	// InternalNetworks is a list of networks whose clients are service-to-service traffic, logged as `traffic_origin=internal`. Any of InternalNetworks, InternalClientCerts or ExternalHeader enables the `traffic_origin` field, which is "external" for traffic not classified as internal. Default is an empty slice.
+	InternalNetworks []net.IPNet
+	// InternalClientCerts classifies requests authenticated with a verified TLS client certificate (mTLS) as internal. Default is false.
+	InternalClientCerts bool
+	// ExternalHeader is the key of a header set by the edge gateway on user-facing traffic. Requests carrying it are classified as external, whatever their address or certificate. Default is empty.
+	ExternalHeader string
 	// Logger is the logrus.Logger used. If not given, logrus.StandardLogger() is used
 	Logger *logrus.Logger
+	// ErrorLogger is the logrus.Logger entries of 4xx and 5xx responses are written to instead of Logger, each with its own formatter and output, mirroring the split between access and error logs. Default is nil, and thus Logger is used.
+	ErrorLogger *logrus.Logger
+	// TeeLoggers are other logrus.Loggers every entry is also written to, e.g. a local file and a remote sink, each with its own level, formatter and output. Default is an empty slice.
+	TeeLoggers []*logrus.Logger
 	// IgnoredRequestURIs is a list of path values we do not want logged out. Exact match only!
 	IgnoredRequestURIs []string
+	// IgnoredMethods is a list of request methods we do not want logged out, e.g. `[]string{"OPTIONS", "HEAD"}`. Default is an empty slice.
+	IgnoredMethods []string
+	// IgnoredStatusCodes is a list of response statuses we do not want logged out, e.g. `[]int{http.StatusSwitchingProtocols}`. Default is an empty slice.
+	IgnoredStatusCodes []int
+	// IgnoredUserAgents is a list of User-Agent prefixes whose requests we do not want logged out, e.g. ProbeUserAgents to skip load balancer and Kubernetes health checks wherever they are sent. Default is an empty slice.
+	IgnoredUserAgents []string
+	// SuccessSampleRate is the fraction, between 0 and 1, of successful (1xx, 2xx and 3xx) requests logged, picked at random. 4xx and 5xx responses are always logged. Default is 0, and thus every request is logged.
+	SuccessSampleRate float64
+	// ErrorsOnly only logs 4xx and 5xx responses, e.g. to quieten a noisy service through AdminHandler. Default is false.
+	ErrorsOnly bool
+	// RateLimit is the number of entries logged per second for each RateLimitKey, beyond which entries are suppressed. The number of suppressed entries is logged for each key, as `rate_limit_key` and `rate_limit_suppressed`, once every RateLimitSummaryInterval. Default is 0, and thus no entry is suppressed.
+	RateLimit float64
+	// RateLimitBurst is the number of entries logged in a burst for each RateLimitKey, before RateLimit applies. Default is RateLimit, rounded up.
+	RateLimitBurst int
+	// RateLimitKey returns the key under which the entry of r, whose logged client address is addr, is rate limited, e.g. RateLimitByClient. Default is RateLimitByPath.
+	RateLimitKey func(r *http.Request, addr string) string
+	// RateLimitSummaryInterval is the interval at which the number of entries suppressed by RateLimit is logged. Summaries are logged even if no request follows, and once more by Close. Default is 1 minute.
+	RateLimitSummaryInterval time.Duration
+	// AsyncQueueSize is the number of entries queued for AsyncWorkers to format and write in the background, so that slow log outputs do not delay responses. See AsyncQueueFull for what happens once the queue is full. Default is 0, and thus entries are written before the middleware returns.
+	AsyncQueueSize int
+	// AsyncWorkers is the number of goroutines writing queued entries, in no particular order when more than one. Default is 1.
+	AsyncWorkers int
+	// AsyncQueueFull selects what happens to entries when the AsyncQueueSize queue is full: AsyncBlock delays the response until there is room, AsyncDrop drops the entry and counts it in Dropped. Default is AsyncBlock.
+	AsyncQueueFull AsyncQueueFull
+	// AsyncEntryTimeout is how long entries may wait in the AsyncQueueSize queue: entries still queued after it are dropped rather than written, and counted in Expired, so that an output outage does not leave a backlog of stale entries behind. Default is 0, and thus entries never expire.
+	AsyncEntryTimeout time.Duration
+	// AsyncSync selects the entries written synchronously rather than through the AsyncQueueSize queue, e.g. AsyncSyncCritical, so that critical entries are neither dropped under AsyncDrop nor lost if the process dies before the queue is drained. It is given the request the entry is about, nil for entries written with Log, the status of its response, 0 when not known yet, and the level of the entry. Default is nil, and thus every entry is queued.
+	AsyncSync func(r *http.Request, status int, level logrus.Level) bool
+	// RequestIDHeader is the header key holding the request ID, which is logged as `http_request_id`. Default is empty, and thus no request ID is logged, unless TrackReplays is set in which case it is "X-Request-ID".
+	RequestIDHeader string
+	// TrackReplays annotates requests whose ID has been seen before with `replay_of` and `replay_seq` fields, so client retries can be traced. Default is false.
+	TrackReplays bool
+	// ReplayCacheSize is the number of request IDs remembered when TrackReplays is set. Default is 10000.
+	ReplayCacheSize int
+	// ErrorBodySize is the number of bytes of a 5xx response body captured and logged as `http_error_body`. Default is 0, and thus no body is captured.
+	ErrorBodySize int
+	// PayloadEncoder encodes captured payloads, such as ErrorBodySize bytes of a 5xx response body, before they are logged, e.g. GzipBase64 to compress them. The encoding and original size of each payload are logged alongside it. Default is nil, and thus payloads are logged verbatim.
+	PayloadEncoder PayloadEncoder
+	// LevelByStatus logs 4xx responses at Warn level and 5xx responses at Error level. Default is false, and thus every request is logged at Info level.
+	LevelByStatus bool
+	// SlowRequestThreshold logs requests taking longer than it with `slow=true`, at Warn level unless they are already logged at a more severe one. Default is 0, and thus no request is slow.
+	SlowRequestThreshold time.Duration
+	// FieldSet selects the fields logged for each request: FieldSetMinimal for the method, path, status and duration only, or FieldSetVerbose for the standard fields along with the referer, the user agent, every header, redacted, and the TLS connection state. Default is FieldSetStandard.
+	FieldSet FieldSet
+	// OmittedFields is a set of standard fields left out of every entry, e.g. `OmitAddr | OmitProto` for privacy-sensitive deployments. Default is 0 (none).
+	OmittedFields StandardFields
+	// LogReferer logs the Referer request header as `http_referer`. Default is false.
+	LogReferer bool
+	// LogUserAgent logs the User-Agent request header as `http_user_agent`. Default is false.
+	LogUserAgent bool
+	// UserAgentParser classifies the User-Agent request header, logged as `ua_browser`, `ua_os` and `ua_is_bot`, e.g. SimpleUserAgentParser. Default is nil, and thus User-Agents are not classified.
+	UserAgentParser UserAgentParser
+	// GeoResolver locates the client address of requests, logged as `geo_country` and `geo_asn`. Default is nil, and thus addresses are not located.
+	GeoResolver GeoResolver
+	// GeoCacheSize is the number of client addresses whose location is remembered when GeoResolver is set. Default is 10000.
+	GeoCacheSize int
+	// ReverseDNS logs the host name of the PTR record of the client address of requests as `http_client_host`. Lookups are made in the background and cached, so an address is only logged with its host name once it has been looked up, and requests never wait on DNS. Host names are not logged when AnonymizeAddr or AddrTransform is set, as they commonly embed the address. Default is false.
+	ReverseDNS bool
+	// ReverseDNSTimeout is the time after which reverse DNS lookups are abandoned, and the address is remembered without host name. Default is 1s.
+	ReverseDNSTimeout time.Duration
+	// ReverseDNSCacheSize is the number of client addresses whose host name is remembered when ReverseDNS is set. Default is 10000.
+	ReverseDNSCacheSize int
+	// LogBasicAuthUser logs the username of the Basic credentials of requests, never their password, as `http_user`, as Apache and nginx access logs do. Default is false.
+	LogBasicAuthUser bool
+	// JWTClaims maps the names of the claims of the JWT Bearer token of requests to the keys they are logged under, e.g. `map[string]string{"sub": logger.FieldUser, "azp": logger.FieldClientID}`. Tokens are only decoded, not verified, unless JWTVerifier is set. Default is nil, and thus no claim is logged.
+	JWTClaims map[string]string
+	// JWTVerifier verifies the JWT Bearer token of requests and returns its claims, or an error for which none of them are logged. Default is nil, and thus tokens are decoded without being verified.
+	JWTVerifier func(token string) (map[string]interface{}, error)
+	// APIKeyHeader is the request header key carrying API keys, which are logged as a short fingerprint, `http_api_key_fingerprint`, so that keys can be told apart without their values ever being stored. Its value is always redacted. Default is empty (disabled).
+	APIKeyHeader string
+	// APIKeySecret is the HMAC key of the APIKeyHeader fingerprints, so that they cannot be matched against guessed keys. Default is empty, and thus fingerprints are plain SHA256 digests.
+	APIKeySecret []byte
+	// BotHeaders logs the crawler verification headers of the request as normalized fields: Web Bot Auth signatures (`bot_signature_agent`, `bot_signature_keyid`, `bot_signature_tag`, `bot_signature_expired`), Cloudflare bot management (`bot_verified`, `bot_score`) and the crawler operator (`bot_from`). Signatures are not verified. Default is false.
+	BotHeaders bool
+	// RequestHeaders is a list of request header keys logged as fields, e.g. `[]string{"Content-Type", "X-Api-Version"}`. Default is an empty slice, and thus no request header is logged.
+	RequestHeaders []string
+	// RequestHeaderPrefix is prepended to the lower-cased, underscore separated header key to form the field key of each of RequestHeaders. Default is "http_req_", which logs "X-Api-Version" as `http_req_x_api_version`.
+	RequestHeaderPrefix string
+	// ResponseHeaders is a list of response header keys logged as fields, as they were when the response headers were written. Default is an empty slice, and thus no response header is logged.
+	ResponseHeaders []string
+	// ResponseHeaderPrefix is the ResponseHeaders counterpart of RequestHeaderPrefix. Default is "http_resp_", which logs "Cache-Control" as `http_resp_cache_control`.
+	ResponseHeaderPrefix string
+	// ResponseTrailers is a list of response trailer keys logged as fields once the handler returns, whether they were declared in the Trailer header or set with http.TrailerPrefix, e.g. `[]string{"Grpc-Status", "Grpc-Message"}`. Default is an empty slice.
+	ResponseTrailers []string
+	// ResponseTrailerPrefix is the ResponseTrailers counterpart of RequestHeaderPrefix. Default is "http_trailer_", which logs "Grpc-Status" as `http_trailer_grpc_status`.
+	ResponseTrailerPrefix string
+	// RedactedHeaders is a list of header keys whose values are replaced by "REDACTED" wherever they are logged, in addition to DefaultRedactedHeaders (`Authorization`, `Cookie`, `Set-Cookie` and `X-Api-Key`). Default is an empty slice.
+	RedactedHeaders []string
+	// HeaderRedactor is called with the canonical key and the value of every header that is logged and not already redacted, and returns the value to log instead. Default is nil, and thus values are logged verbatim.
+	HeaderRedactor func(name, value string) string
+	// DurationFormat selects how `http_duration`, `http_ttfb` and the other durations are logged, e.g. DurationMilliseconds for log pipelines that can only aggregate numbers. The logparse package only parses the default format. Default is DurationString, which logs Go duration strings such as "4.511µs".
+	DurationFormat DurationFormat
+	// DurationSeconds additionally logs the request duration as a number of seconds, `http_duration_seconds`, for log stores computing percentiles natively. Default is false.
+	DurationSeconds bool
+	// URIFields selects whether the request URI is logged as `http_uri` (URICombined), as separate `http_path` and `http_query` fields (URISplit), or both (URIBoth). Default is URICombined.
+	URIFields URIFields
+	// RedactedQueryParams is a list of query parameter names, matched case-insensitively, whose values are replaced by "REDACTED" in the logged URI, e.g. `[]string{"token", "password", "api_key"}`. Default is an empty slice.
+	RedactedQueryParams []string
+	// PathNormalizer is called with the request path, and returns the route template logged as `http_route`, e.g. CollapseIDs to log "/orders/12345" as "/orders/{id}". It is not called for requests matched by a ServeMux pattern, which is logged instead. Default is nil, and thus no route is logged for other requests.
+	PathNormalizer func(path string) string
+	// NormalizeAddr logs the bare IP address of the client, in its canonical form, e.g. "[::1]:1234" is logged as "::1". Default is false.
+	NormalizeAddr bool
+	// LogPort logs the port of `r.RemoteAddr` as `http_port`, when it is the logged address. Default is false.
+	LogPort bool
+	// LogProtocol logs the normalized HTTP version of requests as `http_version`, e.g. "1.1", "2" or "3", including from HTTP/3 servers that only set `Request.Proto`, and the protocol negotiated through ALPN as `tls_protocol`, e.g. "h2". Default is false.
+	LogProtocol bool
+	// AnonymizeAddr zeroes the last octet of IPv4 addresses, and the last 80 bits of IPv6 addresses, before they are logged as `http_addr`. Default is false.
+	AnonymizeAddr bool
+	// AddrTransform is applied to the client address, after AnonymizeAddr, right before it is logged as `http_addr`. See HashIP for a salted hashing transform. Default is nil.
+	AddrTransform func(addr string) string
+	// ProbePaths is a list of paths, such as load balancer health checks, that are expected to be requested regularly. A Warn entry is logged, and OnProbeMissing is called, when one of them has not been requested for ProbeTimeout. Exact match only! Default is an empty slice.
+	ProbePaths []string
+	// ProbeTimeout is how long ProbePaths may go without a request before being reported. Default is 30 seconds.
+	ProbeTimeout time.Duration
+	// OnProbeMissing is called with the probe path and the time it was last requested (or the time the Logger was created), when ProbePaths stop being requested. Default is nil.
+	OnProbeMissing func(path string, lastSeen time.Time)
+	// TraceContext logs the trace ID, parent span ID and sampled flag of the W3C `traceparent` request header as `trace_id`, `span_id` and `trace_sampled`. Default is false.
+	TraceContext bool
+	// TraceStateKey is the vendor key under which the sampled and logged decisions are added to the W3C `tracestate` request header, e.g. `mylogger=s:1;l:1`, so handlers propagating it let downstream services honor them. The logged decision is made before the request is served: it accounts for IgnoredRequestURIs and the like, and SuccessSampleRate, but not for what depends on the outcome of the request, i.e. failed requests logged although not sampled in, IgnoredStatusCodes, ErrorsOnly, Suppress and RateLimit. See TraceState. Default is empty, and thus tracestate is left untouched.
+	TraceStateKey string
+	// DebugHeader is the request header key asking for a request to be logged verbosely: regardless of the ignore lists, sampling and rate limits, with `debug=true`, all of its request and response headers, redacted, and DebugBodySize bytes of its response body as `http_response_body`. It is only honored with DebugSecret as its value, or from DebugNetworks, and its value is always redacted. Default is empty, and thus no request is logged verbosely.
+	DebugHeader string
+	// DebugSecret is the DebugHeader value enabling verbose logging. Default is empty, and thus only DebugNetworks can enable it.
+	DebugSecret string
+	// DebugNetworks is a list of networks whose requests enable verbose logging with any DebugHeader value. Default is an empty slice.
+	DebugNetworks []net.IPNet
+	// DebugBodySize is the number of bytes of the response body logged for verbose requests. Default is 1024.
+	DebugBodySize int
+	// StreamHeartbeat is the minimum interval between the "Stream in progress" entries logged on the flushes of long-lived event streams, `text/event-stream` responses, whose completion entries carry `http_stream_flushes` and `http_stream_duration` regardless. Default is 0 (disabled).
+	StreamHeartbeat time.Duration
+	// LogRequestSize logs the Content-Length declared by requests, if any, as `http_request_length`, and the number of request body bytes read by the handler as `http_request_size`. Default is false.
+	LogRequestSize bool
+	// LogRequestBodyRead logs the time the handler spent reading the request body as `http_request_read_time`, telling slow clients from slow handlers, and whether it read the body entirely as `http_request_consumed`. Default is false.
+	LogRequestBodyRead bool
+	// LogContentType logs the Content-Type and Content-Encoding response headers, as set by the handler when the headers were written, as `http_content_type` and `http_content_encoding`. Default is false.
+	LogContentType bool
+	// LogRateLimitHeaders logs the Retry-After response header of any response, in seconds, as `http_retry_after`, and the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset response headers, or their X-RateLimit- counterparts, as `http_ratelimit_limit`, `http_ratelimit_remaining` and `http_ratelimit_reset`, when present. Default is false, and thus Retry-After is only logged for 429 Too Many Requests responses.
+	LogRateLimitHeaders bool
+	// ProxyTimings logs the time spent by the request in the proxies and CDNs in front of the handler, as normalized fields: `proxy_upstream_time_ms` from X-Envoy-Upstream-Service-Time, `proxy_queue_time_ms` from X-Request-Start and `cdn_cache_status` from CF-Cache-Status. Default is false.
+	ProxyTimings bool
+	// ArrivalRateWindow is the sliding window over which the arrival rate of requests is estimated for each client address and path. The estimate is logged as `http_arrival_rate`, in requests per second, alongside the `http_retry_after` seconds of 429 Too Many Requests responses. Default is 0, and thus arrival rates are not tracked.
+	ArrivalRateWindow time.Duration
+	// AccessLog is written a line for every logged request, in AccessLogFormat, for tools consuming classic access logs only. Default is nil (disabled).
+	AccessLog io.Writer
+	// AccessLogFormat selects the format of the AccessLog lines: AccessLogCombined, the Apache Combined Log Format, or AccessLogALB, the format of AWS Application Load Balancer access logs. Default is AccessLogCombined.
+	AccessLogFormat AccessLogFormat
+	// AccessLogOnly writes requests to AccessLog only, rather than also logging them to Logger. Default is false.
+	AccessLogOnly bool
+	// CloudLogging moves the request fields under `httpRequest`, as the Google Cloud Logging LogEntry expects, and logs the `severity` of entries, along with `logging.googleapis.com/trace` from the X-Cloud-Trace-Context or traceparent request header. Default is false.
+	CloudLogging bool
+	// CloudProject is the Google Cloud project ID `logging.googleapis.com/trace` is qualified with. Default is the value of the GOOGLE_CLOUD_PROJECT environment variable, if any, and otherwise the bare trace ID is logged.
+	CloudProject string
+	// Preset is the name of a curated configuration ("minimal", "verbose", "security", "ecs", "gcp", "cdn" or "dev") filling in the options left unset. Default is the value of the LOGGER_PRESET environment variable, if any.
+	Preset string
 }
 
-// Logger is a HTTP middleware handler that logs a request. Outputted information includes status, method, URL, remote address, size, and the time it took to process the request.
+// Logger is a HTTP middleware handler that logs a request. Outputted information includes status, method, host, scheme, URL, remote address, size, and the time it took to process the request.
 type Logger struct {
-	opt Options
+	opt             Options
+	replays         *replayTracker
+	requestHeaders  []headerField
+	responseHeaders []headerField
+	trailers        []headerField
+	snapshotHeaders []string
+	snapshotIndex   map[string]int
+	redactor        *headerRedactor
+	redactedParams  map[string]bool
+	// customFields are the fields added to every entry: the process fields, renamed, and CustomFields.
+	customFields logrus.Fields
+	geo          *geoCache
+	rdns         *reverseDNS
+	probes       *probeWatchdog
+	arrivals     *arrivalRates
+	limiter      *rateLimiter
+	async        *asyncQueue
+	// accessLogMu serializes the lines written to AccessLog.
+	accessLogMu *sync.Mutex
+	// random returns a pseudo-random number in [0, 1), for SuccessSampleRate.
+	random func() float64
+	// live holds the Logger serving requests, which SetOptions replaces by a reconfigured copy sharing the background state above.
+	live *liveLogger
 }
 
-// New returns a new Logger instance.
+// New returns a new Logger instance. When several Options are given, they are merged: the non-zero fields of each of them override the ones before it.
 func New(opts ...Options) *Logger {
-	var o Options
-	if len(opts) == 0 {
-		o = Options{}
-	} else {
-		o = opts[0]
+	l := newLogger(mergeOptions(opts...))
+	o := l.opt
+	l.random = rand.Float64
+	l.accessLogMu = &sync.Mutex{}
+	l.live = &liveLogger{}
+	l.live.current.Store(l)
+
+	// Determine replay tracking.
+	if o.TrackReplays {
+		if o.ReplayCacheSize <= 0 {
+			l.opt.ReplayCacheSize = 10000
+		}
+		l.replays = newReplayTracker(l.opt.ReplayCacheSize)
+	}
+
+	// Determine reverse DNS lookups.
+	if o.ReverseDNS {
+		if o.ReverseDNSTimeout <= 0 {
+			l.opt.ReverseDNSTimeout = time.Second
+		}
+		if o.ReverseDNSCacheSize <= 0 {
+			l.opt.ReverseDNSCacheSize = 10000
+		}
+		l.rdns = newReverseDNS(l.opt.ReverseDNSCacheSize, l.opt.ReverseDNSTimeout)
+	}
+
+	// Determine arrival rate tracking.
+	if o.ArrivalRateWindow > 0 {
+		l.arrivals = newArrivalRates(o.ArrivalRateWindow, 10000)
+	}
+
+	// Determine rate limiting.
+	if o.RateLimit > 0 {
+		if o.RateLimitBurst <= 0 {
+			l.opt.RateLimitBurst = int(math.Ceil(o.RateLimit))
+		}
+		if o.RateLimitSummaryInterval <= 0 {
+			l.opt.RateLimitSummaryInterval = time.Minute
+		}
+		l.limiter = newRateLimiter(o.RateLimit, l.opt.RateLimitBurst, l.opt.RateLimitSummaryInterval, 10000, l.rateLimited)
+	}
+
+	// Determine asynchronous writing.
+	if o.AsyncQueueSize > 0 {
+		if o.AsyncWorkers <= 0 {
+			l.opt.AsyncWorkers = 1
+		}
+		l.async = newAsyncQueue(o.AsyncQueueSize, o.AsyncEntryTimeout)
+		l.async.workers.Add(l.opt.AsyncWorkers)
+		for i := 0; i < l.opt.AsyncWorkers; i++ {
+			go l.writeQueued()
+		}
+	}
+
+	// Determine probe watchdog.
+	if len(o.ProbePaths) > 0 {
+		if o.ProbeTimeout <= 0 {
+			l.opt.ProbeTimeout = 30 * time.Second
+		}
+		l.probes = newProbeWatchdog(o.ProbePaths, l.opt.ProbeTimeout, l.probeMissing)
+	}
+
+	return l
+}
+
+// newLogger returns a Logger configured with o, once its defaults are filled in, but without any background state.
+func newLogger(o Options) *Logger {
+	// Determine preset.
+	if len(o.Preset) == 0 {
+		o.Preset = os.Getenv(PresetEnv)
+	}
+	applyPreset, knownPreset := presets[o.Preset]
+	if knownPreset {
+		applyPreset(&o)
 	}
 
 	// Determine message.
@@ -43,85 +334,768 @@ func New(opts ...Options) *Logger {
 		o.Message = "Request received"
 	}
 
+	// Determine start message.
+	if o.LogRequestStart && len(o.StartMessage) == 0 {
+		o.StartMessage = "Request started"
+	}
+
 	// Determine output logger.
 	if o.Logger == nil {
 		// Default is logrus Standard Logger.
 		o.Logger = logrus.StandardLogger()
 	}
 
-	return &Logger{
-		opt: o,
+	// Determine request header field keys.
+	if len(o.RequestHeaderPrefix) == 0 {
+		o.RequestHeaderPrefix = "http_req_"
+	}
+	if len(o.ResponseHeaderPrefix) == 0 {
+		o.ResponseHeaderPrefix = "http_resp_"
+	}
+	if len(o.ResponseTrailerPrefix) == 0 {
+		o.ResponseTrailerPrefix = "http_trailer_"
+	}
+	if !knownPreset && len(o.Preset) > 0 {
+		o.Logger.Warnf("logger: unknown preset %q, ignoring it", o.Preset)
+	}
+
+	// Determine request ID header.
+	if o.TrackReplays && len(o.RequestIDHeader) == 0 {
+		o.RequestIDHeader = "X-Request-ID"
 	}
+
+	// Determine rate limiting key.
+	if o.RateLimit > 0 && o.RateLimitKey == nil {
+		o.RateLimitKey = RateLimitByPath
+	}
+
+	// Determine client address location.
+	if o.GeoResolver != nil && o.GeoCacheSize <= 0 {
+		o.GeoCacheSize = 10000
+	}
+
+	// Determine Cloud Logging project.
+	if o.CloudLogging && len(o.CloudProject) == 0 {
+		o.CloudProject = os.Getenv(CloudProjectEnv)
+	}
+
+	// Determine verbose logging.
+	redactedHeaders := o.RedactedHeaders
+	if len(o.DebugHeader) > 0 {
+		redactedHeaders = append(append([]string(nil), redactedHeaders...), o.DebugHeader)
+		if o.DebugBodySize <= 0 {
+			o.DebugBodySize = 1024
+		}
+	}
+	if len(o.APIKeyHeader) > 0 {
+		redactedHeaders = append(append([]string(nil), redactedHeaders...), o.APIKeyHeader)
+	}
+
+	l := &Logger{
+		opt:             o,
+		requestHeaders:  newHeaderFields(o.RequestHeaderPrefix, o.RequestHeaders),
+		responseHeaders: newHeaderFields(o.ResponseHeaderPrefix, o.ResponseHeaders),
+		trailers:        newHeaderFields(o.ResponseTrailerPrefix, o.ResponseTrailers),
+		redactor:        newHeaderRedactor(redactedHeaders, o.HeaderRedactor),
+		redactedParams:  newRedactedParams(o.RedactedQueryParams),
+		customFields:    newCustomFields(o),
+	}
+	if o.GeoResolver != nil {
+		l.geo = newGeoCache(o.GeoResolver, o.GeoCacheSize)
+	}
+
+	// Determine the response headers to snapshot.
+	for _, h := range l.responseHeaders {
+		l.snapshotHeader(h.name)
+	}
+
+	if o.LogRateLimitHeaders {
+		l.snapshotRateLimitHeaders()
+	}
+	if o.LogContentType {
+		l.snapshotHeader("Content-Type")
+		l.snapshotHeader("Content-Encoding")
+	}
+	if o.ProxyTimings {
+		l.snapshotHeader(headerEnvoyUpstreamServiceTime)
+		l.snapshotHeader(headerCFCacheStatus)
+	}
+
+	return l
+}
+
+// rateLimited reports that n entries for key were suppressed by RateLimit.
+func (l *Logger) rateLimited(key string, n int) {
+	l = l.current()
+	fields := logrus.Fields{
+		FieldRateLimitKey:        key,
+		FieldRateLimitSuppressed: n,
+	}
+	renameFields(fields, l.opt.FieldNames)
+	l.opt.Logger.WithFields(fields).Warn("Log entries suppressed")
 }
 
-// Handler wraps an HTTP handler and logs the request as necessary.
+// probeMissing reports that path has not been requested since lastSeen.
+func (l *Logger) probeMissing(path string, lastSeen time.Time) {
+	l = l.current()
+	fields := logrus.Fields{
+		FieldProbePath:     path,
+		FieldProbeLastSeen: lastSeen,
+	}
+	renameFields(fields, l.opt.FieldNames)
+	l.opt.Logger.WithFields(fields).Warn("Health check probe missing")
+
+	if l.opt.OnProbeMissing != nil {
+		l.opt.OnProbeMissing(path, lastSeen)
+	}
+}
+
+// Handler wraps an HTTP handler and logs the request as necessary. Requests whose handler panics are logged at Error level with the panic value as `http_panic`, and a 500 status unless a response was written, before the panic resumes.
 func (l *Logger) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+		l.serve(w, r, next)
+	})
+}
 
-		crw := newCustomResponseWriter(w)
-		next.ServeHTTP(crw, r)
+// HandlerWithName wraps an HTTP handler as Handler does, and logs its requests with name as `http_handler`, which groups entries by endpoint more stably than their URI. When next is served within another Handler of l, e.g. that of a ServeMux, the request is logged once, by the outer one, with name.
+func (l *Logger) HandlerWithName(name string, next http.Handler) http.Handler {
+	named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if state, ok := r.Context().Value(stateKey).(*requestState); ok {
+			state.handler = name
+		}
+		next.ServeHTTP(w, r)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Unlike a Handler applied twice, a named handler within a Handler is expected, and not warned about.
+		if state, ok := r.Context().Value(stateKey).(*requestState); ok && state.live == l.live {
+			named.ServeHTTP(w, r)
+			return
+		}
+		l.serve(w, r, named)
+	})
+}
 
-		for _, ignoredURI := range l.opt.IgnoredRequestURIs {
-			if ignoredURI == r.RequestURI {
-				return
-			}
+// ServeHTTP serves r with next and logs the request as necessary, as the Handler of next would, so that the Logger slots into negroni chains, e.g. `n.Use(l)`.
+func (l *Logger) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	l.serve(w, r, next)
+}
+
+// serve serves r with next, and logs the request as necessary.
+func (l *Logger) serve(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	l = l.current()
+
+	// Only the outermost of the middlewares of a Logger applied twice in a chain logs the request.
+	if state, ok := r.Context().Value(stateKey).(*requestState); ok && state.live == l.live {
+		l.live.nested.Do(func() {
+			l.opt.Logger.Warn("logger: middleware applied more than once in a chain, only the outermost logs requests")
+		})
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	if l.probes != nil {
+		l.probes.seen(r.URL.Path)
+	}
+
+	// Every arrival of a request ID counts towards its replays, whether the request is logged or not.
+	var replaySeq int
+	if l.replays != nil && len(l.opt.RequestIDHeader) > 0 {
+		if id := r.Header.Get(l.opt.RequestIDHeader); len(id) > 0 {
+			replaySeq = l.replays.observe(id)
+		}
+	}
+
+	// Ignored requests are served untouched, unless their tracestate is to be propagated or they are to be observed.
+	debug := len(l.opt.DebugHeader) > 0 && l.debugRequest(r)
+	ignored := !debug && l.ignored(r)
+	if ignored && len(l.opt.TraceStateKey) == 0 && l.opt.OnComplete == nil {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	start := time.Now()
+	state := &requestState{live: l.live}
+	r = r.WithContext(context.WithValue(r.Context(), stateKey, state))
+
+	sampledIn := !ignored && l.sampledIn()
+	var tp traceParent
+	var traced bool
+	if l.opt.TraceContext || len(l.opt.TraceStateKey) > 0 || l.opt.CloudLogging {
+		tp, traced = l.traceContext(r, state, sampledIn)
+	}
+
+	if l.opt.LogRequestStart && !ignored {
+		l.requestStarted(r, start)
+	}
+
+	var arrivalRate float64
+	if l.arrivals != nil {
+		arrivalRate = l.arrivals.observe(l.clientAddr(r)+" "+r.URL.Path, start)
+	}
+
+	// net/http sends the 100 Continue response, if any, on the first read of the body.
+	var body *requestBody
+	continued := expectsContinue(r)
+	if continued || (l.opt.LogRequestSize || l.opt.LogRequestBodyRead) && r.Body != nil && r.Body != http.NoBody {
+		body = &requestBody{ReadCloser: r.Body, start: start, timed: l.opt.LogRequestBodyRead}
+		r.Body = body
+	}
+
+	crw := newCustomResponseWriter(w, start)
+	defer releaseCustomResponseWriter(crw)
+	crw.bodySize = l.opt.ErrorBodySize
+	if debug {
+		crw.bodySize = l.opt.DebugBodySize
+		crw.captureBody = true
+		crw.snapshotAll = true
+	}
+	if l.opt.FieldSet == FieldSetVerbose {
+		crw.snapshotAll = true
+	}
+	crw.headerNames = l.snapshotHeaders
+	if l.opt.StreamHeartbeat > 0 {
+		crw.heartbeatInterval = l.opt.StreamHeartbeat
+		crw.heartbeat = func(crw *customResponseWriter) {
+			l.streamHeartbeat(r, crw)
 		}
+	}
+	recovered := serveHandler(next, crw, r)
+	if recovered != nil {
+		// The request of a panicking handler is logged, then the panic resumes, for net/http or a recovery middleware to handle.
+		defer panic(recovered)
+		if !crw.wroteHeader {
+			crw.status = http.StatusInternalServerError
+		}
+	}
+	// net/http cancels the context of r once serve returns, so a cancellation seen now is the client's.
+	disconnected := r.Context().Err() == context.Canceled
+	// Headers not written by the handler are written by net/http once it returns.
+	crw.snapshotHeaders()
+	duration := time.Since(start)
+
+	if l.opt.OnComplete != nil {
+		l.opt.OnComplete(l.requestInfo(r, crw, start, duration))
+	}
+
+	if state.suppressed {
+		return
+	}
 
-		addr := r.RemoteAddr
-		for _, headerKey := range l.opt.RemoteAddressHeaders {
-			if val := r.Header.Get(headerKey); len(val) > 0 {
-				addr = val
-				break
+	if !debug && (ignored || l.ignoredStatus(crw.status) || !sampledIn && crw.status < 400 || l.opt.ErrorsOnly && crw.status < 400) {
+		return
+	}
+
+	addr, port := l.remoteAddr(r)
+	if l.limiter != nil && !debug {
+		if !l.limiter.allow(l.opt.RateLimitKey(r, addr), time.Now()) {
+			return
+		}
+	}
+	if l.opt.AccessLog != nil {
+		l.writeAccessLog(r, crw, addr, start, duration)
+		if l.opt.AccessLogOnly {
+			return
+		}
+	}
+	fields := newFields()
+	fields[FieldAddr] = addr
+	fields[FieldMethod] = r.Method
+	fields[FieldProto] = r.Proto
+	fields[FieldHost] = r.Host
+	fields[FieldScheme] = l.scheme(r)
+	fields[FieldStatus] = crw.status
+	fields[FieldSize] = crw.size
+	fields[FieldDuration] = formatDuration(duration, l.opt.DurationFormat)
+	fields[FieldTTFB] = formatDuration(crw.ttfb, l.opt.DurationFormat)
+	if l.opt.DurationSeconds {
+		fields[FieldDurationSeconds] = duration.Seconds()
+	}
+	uri := redactQuery(r.RequestURI, l.redactedParams)
+	if l.opt.URIFields != URISplit {
+		fields[FieldURI] = uri
+	}
+	if l.opt.URIFields != URICombined {
+		path, query := splitURI(uri)
+		fields[FieldPath] = path
+		if len(query) > 0 {
+			fields[FieldQuery] = query
+		}
+	}
+	if l.opt.FieldSet == FieldSetMinimal {
+		minimalFields(fields, uri)
+	}
+	if l.opt.OmittedFields != 0 {
+		omitFields(fields, l.opt.OmittedFields)
+	}
+	if route := l.route(r); len(route) > 0 {
+		fields[FieldRoute] = route
+	}
+	if len(state.handler) > 0 {
+		fields[FieldHandler] = state.handler
+	}
+	if len(port) > 0 {
+		fields[FieldPort] = port
+	}
+	if l.opt.LogProtocol {
+		protocolFields(fields, r)
+	}
+	if origin, ok := l.trafficOrigin(r); ok {
+		fields[FieldTrafficOrigin] = origin
+	}
+	if len(l.opt.RequestIDHeader) > 0 {
+		if id := r.Header.Get(l.opt.RequestIDHeader); len(id) > 0 {
+			id = l.redactor.redact(http.CanonicalHeaderKey(l.opt.RequestIDHeader), id)
+			fields[FieldRequestID] = id
+			if replaySeq > 1 {
+				fields[FieldReplayOf] = id
+				fields[FieldReplaySeq] = replaySeq
 			}
 		}
+	}
+	if l.opt.TraceContext && traced {
+		fields[FieldTraceID] = tp.traceID
+		fields[FieldSpanID] = tp.spanID
+		fields[FieldTraceSampled] = tp.sampled
+	}
+	verbose := l.opt.FieldSet == FieldSetVerbose
+	if l.opt.LogReferer || verbose {
+		if val := r.Header.Get("Referer"); len(val) > 0 {
+			fields[FieldReferer] = val
+		}
+	}
+	if ua := r.Header.Get("User-Agent"); len(ua) > 0 {
+		if l.opt.LogUserAgent || verbose {
+			fields[FieldUserAgent] = ua
+		}
+		if l.opt.UserAgentParser != nil {
+			userAgentFields(fields, l.opt.UserAgentParser, ua)
+		}
+	}
+	if l.geo != nil || l.rdns != nil {
+		// The client address is located and looked up before it is anonymized.
+		if ip := parseAddrIP(l.clientAddr(r)); ip != nil {
+			if l.geo != nil {
+				l.geoFields(fields, ip)
+			}
+			// Host names commonly embed the address, so they are not logged when it is anonymized or transformed.
+			if l.rdns != nil && !l.opt.AnonymizeAddr && l.opt.AddrTransform == nil {
+				if host, ok := l.rdns.host(ip); ok {
+					fields[FieldClientHost] = host
+				}
+			}
+		}
+	}
+	if l.opt.LogBasicAuthUser {
+		if user, _, ok := r.BasicAuth(); ok && len(user) > 0 {
+			fields[FieldUser] = user
+		}
+	}
+	if len(l.opt.JWTClaims) > 0 {
+		l.jwtFields(fields, r)
+	}
+	if len(l.opt.APIKeyHeader) > 0 {
+		if key := r.Header.Get(l.opt.APIKeyHeader); len(key) > 0 {
+			fields[FieldAPIKeyFingerprint] = apiKeyFingerprint(key, l.opt.APIKeySecret)
+		}
+	}
+	if l.opt.BotHeaders {
+		botFields(fields, r, start)
+	}
+	for _, h := range l.requestHeaders {
+		if val := headerValue(r.Header, h.name); len(val) > 0 {
+			fields[h.key] = l.redactor.redact(h.name, val)
+		}
+	}
+	for _, h := range l.responseHeaders {
+		if val := l.responseHeader(crw, h.name); len(val) > 0 {
+			fields[h.key] = l.redactor.redact(h.name, val)
+		}
+	}
+	if len(l.trailers) > 0 {
+		// Trailers are set once the headers are written, until the handler returns.
+		h := crw.ResponseWriter.Header()
+		for _, t := range l.trailers {
+			val := headerValue(h, t.name)
+			if len(val) == 0 {
+				val = headerValue(h, http.TrailerPrefix+t.name)
+			}
+			if len(val) > 0 {
+				fields[t.key] = l.redactor.redact(t.name, val)
+			}
+		}
+	}
+	if l.opt.LogContentType {
+		if val := l.responseHeader(crw, "Content-Type"); len(val) > 0 {
+			fields[FieldContentType] = val
+		}
+		if val := l.responseHeader(crw, "Content-Encoding"); len(val) > 0 {
+			fields[FieldContentEncoding] = val
+		}
+	}
+	if crw.stream {
+		fields[FieldStream] = true
+		fields[FieldStreamFlushes] = crw.flushes
+		fields[FieldStreamDuration] = formatDuration(duration-crw.ttfb, l.opt.DurationFormat)
+	}
+	if crw.duplicateStatus != 0 {
+		fields[FieldDuplicateWriteHeader] = true
+		fields[FieldDuplicateStatus] = crw.duplicateStatus
+	}
+	// The number of bytes written is logged as the mismatch itself, so that it is known even under OmitSize.
+	if declared, ok := l.lengthMismatch(r, crw); ok {
+		fields[FieldLengthMismatch] = crw.size
+		fields[FieldContentLength] = declared
+	}
+	if l.opt.LogRateLimitHeaders {
+		l.rateLimitFields(fields, crw, start)
+	}
+	if crw.status == http.StatusTooManyRequests {
+		if secs, ok := parseRetryAfter(crw.Header().Get("Retry-After"), start); ok && !l.opt.LogRateLimitHeaders {
+			fields[FieldRetryAfter] = secs
+		}
+		if l.arrivals != nil {
+			fields[FieldArrivalRate] = arrivalRate
+		}
+	}
+	if l.opt.ProxyTimings {
+		l.proxyTimingFields(fields, r, crw)
+	}
+	if l.opt.LogRequestSize {
+		if r.ContentLength > 0 {
+			fields[FieldRequestLength] = r.ContentLength
+		}
+		if body != nil {
+			fields[FieldRequestSize] = body.size
+		}
+	}
+	if l.opt.LogRequestBodyRead && body != nil {
+		fields[FieldRequestReadTime] = formatDuration(body.readTime, l.opt.DurationFormat)
+		fields[FieldRequestConsumed] = body.eof || r.ContentLength > 0 && body.size >= r.ContentLength
+	}
+	if continued {
+		fields[FieldExpectContinue] = true
+		fields[FieldContinueSent] = body.read
+		if body.firstByte > 0 {
+			fields[FieldContinueWait] = formatDuration(body.firstByte, l.opt.DurationFormat)
+		}
+	}
+	if disconnected {
+		fields[FieldClientDisconnected] = true
+		if cause := cancelCause(r.Context()); cause != nil {
+			fields[FieldCancelCause] = cause.Error()
+		}
+	}
+	if state.timedOut {
+		fields[FieldTimedOut] = true
+		fields[FieldTimeout] = formatDuration(state.timeout, l.opt.DurationFormat)
+	}
+	if state.attempts > 0 {
+		fields[FieldUpstreamAttempts] = state.attempts
+	}
+	if len(state.upstream) > 0 {
+		fields[FieldUpstream] = state.upstream
+	}
+	if state.upstreamDuration > 0 {
+		fields[FieldUpstreamDuration] = formatDuration(state.upstreamDuration, l.opt.DurationFormat)
+	}
+	if len(state.proxyError) > 0 {
+		fields[FieldProxyError] = state.proxyError
+	}
+	if len(crw.informational) > 0 {
+		fields[FieldInformational] = crw.informational
+	}
+	if crw.earlyHints > 0 {
+		fields[FieldEarlyHints] = crw.earlyHints
+		fields[FieldEarlyHintsTime] = formatDuration(crw.earlyHintsTime, l.opt.DurationFormat)
+	}
+	if verbose {
+		l.verboseFields(fields, r, crw)
+	}
+	if debug {
+		l.debugFields(fields, r, crw)
+	} else if len(crw.body) > 0 {
+		l.payloadFields(fields, FieldErrorBody, crw.body)
+	}
 
-		l.opt.Logger.WithFields(logrus.Fields{
-			"http_addr":     addr,
-			"http_method":   r.Method,
-			"http_uri":      r.RequestURI,
-			"http_proto":    r.Proto,
-			"http_status":   crw.status,
-			"http_size":     crw.size,
-			"http_duration": time.Since(start),
-		}).WithFields(l.opt.CustomFields).Info(l.opt.Message)
-	})
+	level := l.statusLevel(crw.status)
+	if recovered != nil {
+		fields[FieldPanic] = fmt.Sprint(recovered)
+		level = logrus.ErrorLevel
+	}
+	if l.opt.SlowRequestThreshold > 0 && duration > l.opt.SlowRequestThreshold {
+		fields[FieldSlow] = true
+		if level > logrus.WarnLevel {
+			level = logrus.WarnLevel
+		}
+	}
+
+	if l.opt.CloudLogging {
+		l.cloudLoggingFields(fields, r, crw, duration, level, tp, traced)
+	}
+
+	renameFields(fields, l.opt.FieldNames)
+	for key, val := range l.customFields {
+		fields[key] = val
+	}
+	if l.opt.FieldsFunc != nil {
+		for key, val := range l.opt.FieldsFunc(r, crw.status, crw.size, duration) {
+			fields[key] = val
+		}
+	}
+	for key, val := range state.fields {
+		fields[key] = val
+	}
+	logger := l.opt.Logger
+	if l.opt.ErrorLogger != nil && crw.status >= 400 {
+		logger = l.opt.ErrorLogger
+	}
+	message := l.opt.Message
+	if msg, ok := l.opt.StatusMessages[crw.status/100]; ok {
+		message = msg
+	}
+	e := logEntry{logger: logger, tee: l.opt.TeeLoggers, time: time.Now(), level: level, message: message, fields: fields, pooled: true}
+	if l.opt.OnLog != nil {
+		e = l.onLog(e, r)
+	}
+	e.sync = l.asyncSync(r, crw.status, e.level)
+	l.emit(e)
+}
+
+// serveHandler serves r with next, and returns the value next panicked with, if any.
+func serveHandler(next http.Handler, w http.ResponseWriter, r *http.Request) (recovered interface{}) {
+	defer func() {
+		recovered = recover()
+	}()
+
+	next.ServeHTTP(w, r)
+	return nil
+}
+
+// onLog passes e to OnLog as a logrus Entry, along with r, and returns the entry as OnLog left it.
+func (l *Logger) onLog(e logEntry, r *http.Request) logEntry {
+	entry := &logrus.Entry{Logger: e.logger, Data: e.fields, Time: e.time, Level: e.level, Message: e.message}
+	l.opt.OnLog(entry, r)
+	pooled := e.pooled && sameFields(entry.Data, e.fields)
+	if entry.Data == nil {
+		entry.Data, pooled = newFields(), true
+	}
+	return logEntry{logger: entry.Logger, tee: e.tee, time: entry.Time, level: entry.Level, message: entry.Message, fields: entry.Data, pooled: pooled}
+}
+
+// sameFields reports whether a and b are the same map, rather than equal ones.
+func sameFields(a, b logrus.Fields) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+// Log writes an entry of fields at level as the middleware writes the entries of requests: with Message, renamed as FieldNames says, along with CustomFields, to ErrorLogger for entries at Warn level or more severe ones, if set, and to TeeLoggers. It is meant for adapters logging requests not served through net/http, e.g. gRPC calls. fields is not retained.
+func (l *Logger) Log(level logrus.Level, fields logrus.Fields) {
+	l = l.current()
+	entry := newFields()
+	for key, val := range fields {
+		entry[key] = val
+	}
+	renameFields(entry, l.opt.FieldNames)
+	for key, val := range l.customFields {
+		entry[key] = val
+	}
+	logger := l.opt.Logger
+	if l.opt.ErrorLogger != nil && level <= logrus.WarnLevel {
+		logger = l.opt.ErrorLogger
+	}
+	status, _ := fields[FieldStatus].(int)
+	l.emit(logEntry{logger: logger, tee: l.opt.TeeLoggers, time: time.Now(), level: level, message: l.opt.Message, fields: entry, sync: l.asyncSync(nil, status, level), pooled: true})
+}
+
+// requestStarted logs the arrival of r at start.
+func (l *Logger) requestStarted(r *http.Request, start time.Time) {
+	addr, _ := l.remoteAddr(r)
+	fields := newFields()
+	fields[FieldAddr] = addr
+	fields[FieldMethod] = r.Method
+	fields[FieldURI] = redactQuery(r.RequestURI, l.redactedParams)
+	if len(l.opt.RequestIDHeader) > 0 {
+		if id := r.Header.Get(l.opt.RequestIDHeader); len(id) > 0 {
+			fields[FieldRequestID] = l.redactor.redact(http.CanonicalHeaderKey(l.opt.RequestIDHeader), id)
+		}
+	}
+	if l.opt.OmittedFields != 0 {
+		omitFields(fields, l.opt.OmittedFields)
+	}
+	renameFields(fields, l.opt.FieldNames)
+	for key, val := range l.customFields {
+		fields[key] = val
+	}
+	l.emit(logEntry{logger: l.opt.Logger, tee: l.opt.TeeLoggers, time: start, level: logrus.InfoLevel, message: l.opt.StartMessage, fields: fields, sync: l.asyncSync(r, 0, logrus.InfoLevel), pooled: true})
+}
+
+// streamHeartbeat logs the progress of the event stream responding to r.
+func (l *Logger) streamHeartbeat(r *http.Request, crw *customResponseWriter) {
+	addr, _ := l.remoteAddr(r)
+	fields := newFields()
+	fields[FieldAddr] = addr
+	fields[FieldMethod] = r.Method
+	fields[FieldURI] = redactQuery(r.RequestURI, l.redactedParams)
+	fields[FieldStatus] = crw.status
+	fields[FieldSize] = crw.size
+	fields[FieldStream] = true
+	fields[FieldStreamFlushes] = crw.flushes
+	fields[FieldStreamDuration] = formatDuration(time.Since(crw.start)-crw.ttfb, l.opt.DurationFormat)
+	if l.opt.OmittedFields != 0 {
+		omitFields(fields, l.opt.OmittedFields)
+	}
+	renameFields(fields, l.opt.FieldNames)
+	for key, val := range l.customFields {
+		fields[key] = val
+	}
+	l.emit(logEntry{logger: l.opt.Logger, tee: l.opt.TeeLoggers, time: time.Now(), level: logrus.InfoLevel, message: "Stream in progress", fields: fields, sync: l.asyncSync(r, crw.status, logrus.InfoLevel), pooled: true})
+}
+
+// lengthMismatch returns the Content-Length declared by the response to r, and whether it differs from the number of bytes written, which truncates the response.
+func (l *Logger) lengthMismatch(r *http.Request, crw *customResponseWriter) (int64, bool) {
+	val := crw.contentLength
+	if len(val) == 0 || r.Method == http.MethodHead || crw.status == http.StatusNoContent || crw.status == http.StatusNotModified {
+		return 0, false
+	}
+	declared, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || declared == int64(crw.size) {
+		return 0, false
+	}
+	return declared, true
+}
+
+// route returns the route template of r, once it has been served: the route set by SetRoute, the ServeMux pattern that matched it, or else its normalized path.
+func (l *Logger) route(r *http.Request) string {
+	if state, ok := r.Context().Value(stateKey).(*requestState); ok && len(state.route) > 0 {
+		return state.route
+	}
+	if route := routePattern(r); len(route) > 0 {
+		return route
+	}
+	if l.opt.PathNormalizer != nil {
+		return l.opt.PathNormalizer(r.URL.Path)
+	}
+	return ""
+}
+
+// ignored reports whether r is not to be logged, regardless of its outcome.
+func (l *Logger) ignored(r *http.Request) bool {
+	return l.ignoredURI(r.RequestURI) || l.ignoredMethod(r.Method) || l.ignoredUserAgent(r.UserAgent())
+}
+
+// ignoredURI reports whether uri is one of IgnoredRequestURIs.
+func (l *Logger) ignoredURI(uri string) bool {
+	for _, ignoredURI := range l.opt.IgnoredRequestURIs {
+		if ignoredURI == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredMethod reports whether method is one of IgnoredMethods.
+func (l *Logger) ignoredMethod(method string) bool {
+	for _, ignoredMethod := range l.opt.IgnoredMethods {
+		if ignoredMethod == method {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredUserAgent reports whether ua starts with one of IgnoredUserAgents.
+func (l *Logger) ignoredUserAgent(ua string) bool {
+	for _, prefix := range l.opt.IgnoredUserAgents {
+		if strings.HasPrefix(ua, prefix) {
+			return true
+		}
+	}
+	return false
 }
 
-type customResponseWriter struct {
-	http.ResponseWriter
-	status int
-	size   int
+// ignoredStatus reports whether status is one of IgnoredStatusCodes.
+func (l *Logger) ignoredStatus(status int) bool {
+	for _, ignoredStatus := range l.opt.IgnoredStatusCodes {
+		if ignoredStatus == status {
+			return true
+		}
+	}
+	return false
 }
 
-func (c *customResponseWriter) WriteHeader(status int) {
-	c.status = status
-	c.ResponseWriter.WriteHeader(status)
+// sampled reports whether a request completed with status is logged under SuccessSampleRate.
+func (l *Logger) sampled(status int) bool {
+	return status >= 400 || l.sampledIn()
 }
 
-func (c *customResponseWriter) Write(b []byte) (int, error) {
-	size, err := c.ResponseWriter.Write(b)
-	c.size += size
-	return size, err
+// sampledIn draws whether a request is logged under SuccessSampleRate should it succeed. It is drawn before the request is served, so that the decision can be propagated in its tracestate.
+func (l *Logger) sampledIn() bool {
+	rate := l.opt.SuccessSampleRate
+	if rate <= 0 || rate >= 1 {
+		return true
+	}
+	return l.random() < rate
 }
 
-func (c *customResponseWriter) Flush() {
-	if f, ok := c.ResponseWriter.(http.Flusher); ok {
-		f.Flush()
+// statusLevel returns the level of the entry of a request completed with status, under LevelByStatus.
+func (l *Logger) statusLevel(status int) logrus.Level {
+	if l.opt.LevelByStatus {
+		switch {
+		case status >= 500:
+			return logrus.ErrorLevel
+		case status >= 400:
+			return logrus.WarnLevel
+		}
 	}
+	return logrus.InfoLevel
 }
 
-func (c *customResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	if hj, ok := c.ResponseWriter.(http.Hijacker); ok {
-		return hj.Hijack()
+type contextKey int
+
+const stateKey contextKey = 0
+
+// requestState is shared, through the request context, between the middleware and the handlers it wraps.
+type requestState struct {
+	// live identifies the Logger serving the request.
+	live       *liveLogger
+	suppressed bool
+	// timedOut is set, along with the timeout, by MarkTimedOut.
+	timedOut bool
+	timeout  time.Duration
+	// attempts, upstream and upstreamDuration are set by the Transport of a ReverseProxy or by RecordAttempt, and proxyError by the ErrorHandler of a ReverseProxy.
+	attempts         int
+	upstream         string
+	upstreamDuration time.Duration
+	proxyError       string
+	// route is set by SetRoute, fields by AddFields, and handler by HandlerWithName.
+	route   string
+	fields  logrus.Fields
+	handler string
+	// traceState is the tracestate header to propagate downstream.
+	traceState string
+}
+
+// SetRoute sets the route pattern of the request carried by ctx, logged as `http_route` by the Logger middleware in place of the ServeMux pattern or PathNormalizer. It is meant to be called by routers and framework adapters once the request is routed, and is a no-op when ctx did not come through the middleware.
+func SetRoute(ctx context.Context, route string) {
+	if state, ok := ctx.Value(stateKey).(*requestState); ok {
+		state.route = route
+	}
+}
+
+// AddFields adds fields to the entry of the request carried by ctx, overriding the CustomFields and FieldsFunc fields of the same keys. It is meant to be called from within a handler, or by framework adapters, and is a no-op when ctx did not come through the middleware.
+func AddFields(ctx context.Context, fields logrus.Fields) {
+	if state, ok := ctx.Value(stateKey).(*requestState); ok {
+		if state.fields == nil {
+			state.fields = make(logrus.Fields, len(fields))
+		}
+		for key, val := range fields {
+			state.fields[key] = val
+		}
 	}
-	return nil, nil, fmt.Errorf("ResponseWriter does not implement the Hijacker interface")
 }
 
-func newCustomResponseWriter(w http.ResponseWriter) *customResponseWriter {
-	// When WriteHeader is not called, it's safe to assume the status will be 200.
-	return &customResponseWriter{
-		ResponseWriter: w,
-		status:         200,
+// Suppress marks the request carried by ctx so that it is not logged by the Logger middleware. It is meant to be called from within a handler, e.g. `logger.Suppress(r.Context())`, and is a no-op when ctx did not come through the middleware.
+func Suppress(ctx context.Context) {
+	if state, ok := ctx.Value(stateKey).(*requestState); ok {
+		state.suppressed = true
 	}
 }
@@ -2,12 +2,15 @@ package logger
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -56,6 +59,120 @@ func TestDefaultConfig(t *testing.T) {
 	expectContainsTrue(t, buf.String(), fmt.Sprintf("http_uri=\"%s\"", url))
 }
 
+func TestNegroniServeHTTP(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RequestURI = "/foo"
+	l.ServeHTTP(res, req, myHandler)
+
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "bar")
+	expectContainsTrue(t, buf.String(), "http_status=200")
+	expectContainsTrue(t, buf.String(), "http_uri=/foo")
+}
+
+func TestSetRoute(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:         logger,
+		PathNormalizer: CollapseIDs,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/42", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetRoute(r.Context(), "/users/:id")
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_route=\"/users/:id\"")
+}
+
+func TestAddFields(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:       logger,
+		CustomFields: logrus.Fields{"tenant": "none"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		AddFields(r.Context(), logrus.Fields{"tenant": "acme", "user_id": 42})
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "tenant=acme")
+	expectContainsTrue(t, buf.String(), "user_id=42")
+}
+
+func TestLog(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	errBuf := bytes.NewBufferString("")
+	errLogger := logrus.New()
+	errLogger.SetOutput(errBuf)
+
+	l := New(Options{
+		Logger:       logger,
+		ErrorLogger:  errLogger,
+		Message:      "Call received",
+		FieldNames:   map[string]string{FieldMethod: "method"},
+		CustomFields: logrus.Fields{"tier": "edge"},
+	})
+
+	fields := logrus.Fields{FieldMethod: "/pkg.Service/Method"}
+	l.Log(logrus.InfoLevel, fields)
+	l.Log(logrus.ErrorLevel, fields)
+
+	expectContainsTrue(t, buf.String(), "msg=\"Call received\"")
+	expectContainsTrue(t, buf.String(), "method=/pkg.Service/Method")
+	expectContainsTrue(t, buf.String(), "tier=edge")
+	expectContainsTrue(t, errBuf.String(), "level=error")
+	expectContainsFalse(t, buf.String(), "level=error")
+	expect(t, fields[FieldMethod], "/pkg.Service/Method")
+}
+
+func TestHandlerWithName(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/42", nil)
+	l.HandlerWithName("get-user", myHandler).ServeHTTP(res, req)
+
+	expect(t, res.Body.String(), "bar")
+	expectContainsTrue(t, buf.String(), "http_handler=get-user")
+
+	// Within another Handler of the same Logger, the outer one logs the name.
+	buf.Reset()
+	mux := http.NewServeMux()
+	mux.Handle("/users/", l.HandlerWithName("get-user", myHandler))
+	l.Handler(mux).ServeHTTP(httptest.NewRecorder(), req)
+
+	expectContainsTrue(t, buf.String(), "http_handler=get-user")
+	expectContainsFalse(t, buf.String(), "more than once")
+	expect(t, strings.Count(buf.String(), "Request received"), 1)
+}
+
 func TestDefaultConfigPostError(t *testing.T) {
 	buf := bytes.NewBufferString("")
 	logger := logrus.New()
@@ -134,6 +251,37 @@ func TestCustomFields(t *testing.T) {
 	expectContainsFalse(t, buf.String(), "foo=\"bar\"")
 }
 
+func TestFieldNames(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:       logger,
+		CustomFields: logrus.Fields{"status": "custom"},
+		FieldNames: map[string]string{
+			FieldStatus: "status",
+			FieldURI:    "url.path",
+			// Keys can be swapped.
+			FieldMethod: FieldProto,
+			FieldProto:  FieldMethod,
+		},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RequestURI = "/foo"
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "url.path=/foo")
+	expectContainsTrue(t, buf.String(), "http_method=HTTP/1.1")
+	expectContainsTrue(t, buf.String(), "http_proto=GET")
+	// CustomFields are not renamed, and win over renamed fields.
+	expectContainsTrue(t, buf.String(), "status=custom")
+	expectContainsFalse(t, buf.String(), "http_status")
+	expectContainsFalse(t, buf.String(), "http_uri")
+}
+
 func TestDefaultRemoteAddress(t *testing.T) {
 	buf := bytes.NewBufferString("")
 	logger := logrus.New()
@@ -302,21 +450,1037 @@ func TestIgnoredURIsMatchig(t *testing.T) {
 	expect(t, buf.String(), "")
 }
 
-/* Test Helpers */
-func expect(t *testing.T, a interface{}, b interface{}) {
-	if a != b {
-		t.Errorf("Expected [%v] (type %v) - Got [%v] (type %v)", b, reflect.TypeOf(b), a, reflect.TypeOf(a))
+func TestIgnoredNotWrapped(t *testing.T) {
+	l := New(Options{
+		Logger:             logrus.New(),
+		IgnoredRequestURIs: []string{"/foo"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RequestURI = "/foo"
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expect(t, w, http.ResponseWriter(res))
+		expect(t, r, req)
+	})).ServeHTTP(res, req)
+}
+
+func TestIgnoredMethods(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:         logger,
+		IgnoredMethods: []string{"OPTIONS", "HEAD"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("OPTIONS", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+	req, _ = http.NewRequest("HEAD", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expect(t, buf.String(), "")
+
+	req, _ = http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_method=GET")
+}
+
+func TestIgnoredStatusCodes(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:             logger,
+		IgnoredStatusCodes: []int{http.StatusOK, http.StatusSwitchingProtocols},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expect(t, buf.String(), "")
+
+	l.Handler(myHandlerWithError).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_status=502")
+}
+
+func TestSuccessSampleRate(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:            logger,
+		SuccessSampleRate: 0.25,
+	})
+	var n int
+	l.random = func() float64 {
+		n++
+		return float64(n%4) / 4
+	}
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	for i := 0; i < 8; i++ {
+		l.Handler(myHandler).ServeHTTP(res, req)
 	}
+	for i := 0; i < 8; i++ {
+		l.Handler(myHandlerWithError).ServeHTTP(res, req)
+	}
+
+	expect(t, strings.Count(buf.String(), "http_status=200"), 2)
+	expect(t, strings.Count(buf.String(), "http_status=502"), 8)
 }
 
-func expectContainsTrue(t *testing.T, a, b string) {
-	if !strings.Contains(a, b) {
-		t.Errorf("Expected [%s] to contain [%s]", a, b)
+func TestSuppress(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/poll", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Suppress(r.Context())
+		w.Write([]byte("bar"))
+	})).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "bar")
+	expect(t, buf.String(), "")
+}
+
+func TestSuppressOutsideMiddleware(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/poll", nil)
+
+	// Must not panic when the request never went through the middleware.
+	Suppress(req.Context())
+}
+
+func TestEarlyHints(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("bar"))
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), fmt.Sprintf("http_status=%d", http.StatusOK))
+	expectContainsTrue(t, buf.String(), "http_early_hints=2")
+	expectContainsTrue(t, buf.String(), "http_early_hints_time=")
+	expectContainsTrue(t, buf.String(), "http_informational=\"[103 103]\"")
+}
+
+func TestInformationalImplicitStatus(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusProcessing)
+		w.WriteHeader(http.StatusEarlyHints)
+		w.Write([]byte("bar"))
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_status=200")
+	expectContainsTrue(t, buf.String(), "http_informational=\"[102 103]\"")
+	expectContainsTrue(t, buf.String(), "http_early_hints=1")
+}
+
+func TestNoEarlyHints(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), "http_early_hints")
+}
+
+func TestRequestID(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:          logger,
+		RequestIDHeader: "X-Correlation-ID",
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Correlation-ID", "abc123")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_request_id=abc123")
+	expectContainsFalse(t, buf.String(), "replay_of")
+}
+
+func TestTrackReplays(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:       logger,
+		TrackReplays: true,
+	})
+
+	for i := 1; i <= 3; i++ {
+		buf.Reset()
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/orders", nil)
+		req.Header.Set("X-Request-ID", "abc123")
+		l.Handler(myHandler).ServeHTTP(res, req)
+
+		expectContainsTrue(t, buf.String(), "http_request_id=abc123")
+		if i == 1 {
+			expectContainsFalse(t, buf.String(), "replay_of")
+		} else {
+			expectContainsTrue(t, buf.String(), "replay_of=abc123")
+			expectContainsTrue(t, buf.String(), fmt.Sprintf("replay_seq=%d", i))
+		}
 	}
 }
 
-func expectContainsFalse(t *testing.T, a, b string) {
-	if strings.Contains(a, b) {
-		t.Errorf("Expected [%s] to contain [%s]", a, b)
+func TestPanic(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	for _, written := range []bool{false, true} {
+		buf.Reset()
+		func() {
+			defer func() {
+				expect(t, recover(), "boom")
+			}()
+			req, _ := http.NewRequest("GET", "/foo", nil)
+			l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if written {
+					w.Write([]byte("bar"))
+				}
+				panic("boom")
+			})).ServeHTTP(httptest.NewRecorder(), req)
+		}()
+
+		expectContainsTrue(t, buf.String(), "level=error")
+		expectContainsTrue(t, buf.String(), "http_panic=boom")
+		if written {
+			expectContainsTrue(t, buf.String(), "http_status=200")
+		} else {
+			expectContainsTrue(t, buf.String(), "http_status=500")
+		}
+	}
+}
+
+func TestTrackReplaysUnlogged(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	for _, o := range []Options{
+		{Logger: logger, TrackReplays: true, ErrorsOnly: true},
+		{Logger: logger, TrackReplays: true, SuccessSampleRate: 0.5},
+	} {
+		l := New(o)
+		l.random = func() float64 { return 0.9 }
+
+		// The first arrival is not logged, but counted.
+		buf.Reset()
+		req, _ := http.NewRequest("POST", "/orders", nil)
+		req.Header.Set("X-Request-ID", "abc123")
+		l.Handler(myHandler).ServeHTTP(httptest.NewRecorder(), req)
+		expect(t, buf.String(), "")
+
+		l.Handler(myHandlerWithError).ServeHTTP(httptest.NewRecorder(), req)
+		expectContainsTrue(t, buf.String(), "replay_of=abc123")
+		expectContainsTrue(t, buf.String(), "replay_seq=2")
 	}
 }
+
+func TestTrackReplaysRedacted(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:          logger,
+		TrackReplays:    true,
+		RedactedHeaders: []string{"X-Request-ID"},
+	})
+
+	req, _ := http.NewRequest("POST", "/orders", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	l.Handler(myHandler).ServeHTTP(httptest.NewRecorder(), req)
+	l.Handler(myHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	expectContainsTrue(t, buf.String(), "replay_of=REDACTED")
+	expectContainsTrue(t, buf.String(), "replay_seq=2")
+	expectContainsFalse(t, buf.String(), "abc123")
+}
+
+func TestTrackReplaysEviction(t *testing.T) {
+	tracker := newReplayTracker(2)
+
+	expect(t, tracker.observe("a"), 1)
+	expect(t, tracker.observe("b"), 1)
+	expect(t, tracker.observe("a"), 2)
+	expect(t, tracker.observe("c"), 1)
+	// "b" was the least recently seen, so it has been forgotten.
+	expect(t, tracker.observe("b"), 1)
+	expect(t, tracker.observe("a"), 1)
+}
+
+func TestErrorBody(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:        logger,
+		ErrorBodySize: 3,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandlerWithError).ServeHTTP(res, req)
+
+	expect(t, strings.TrimSpace(res.Body.String()), http.StatusText(http.StatusBadGateway))
+	expectContainsTrue(t, buf.String(), "http_error_body=Bad")
+	expectContainsFalse(t, buf.String(), "http_error_body=\"Bad ")
+}
+
+func TestErrorBodyNotCapturedOnSuccess(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:        logger,
+		ErrorBodySize: 1024,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), "http_error_body")
+}
+
+func TestResponseWriterReuse(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:        logger,
+		ErrorBodySize: 8,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	for i := 0; i < 10; i++ {
+		l.Handler(myHandlerWithError).ServeHTTP(res, req)
+	}
+
+	// Recycled writers carry nothing over from the requests they wrapped before.
+	buf.Reset()
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "http_status=200")
+	expectContainsTrue(t, buf.String(), "http_size=3 ")
+	expectContainsFalse(t, buf.String(), "http_error_body")
+}
+
+func TestLevelByStatus(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:        logger,
+		LevelByStatus: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandlerWithError).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "level=error")
+
+	buf.Reset()
+	res = httptest.NewRecorder()
+	l.Handler(http.NotFoundHandler()).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "level=warning")
+
+	buf.Reset()
+	res = httptest.NewRecorder()
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "level=info")
+}
+
+func TestSlowRequestThreshold(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:               logger,
+		SlowRequestThreshold: 5 * time.Millisecond,
+	})
+	slowHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "level=info")
+	expectContainsFalse(t, buf.String(), "slow=")
+
+	buf.Reset()
+	l.Handler(slowHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "level=warning")
+	expectContainsTrue(t, buf.String(), "slow=true")
+
+	// Slow requests are not logged at a less severe level than their status.
+	l.opt.LevelByStatus = true
+	buf.Reset()
+	l.Handler(slowHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "level=error")
+	expectContainsTrue(t, buf.String(), "slow=true")
+}
+
+func TestRequestHeaders(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:         logger,
+		RequestHeaders: []string{"content-type", "X-Api-Version", "Accept"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Api-Version", "2")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_req_content_type=application/json")
+	expectContainsTrue(t, buf.String(), "http_req_x_api_version=2")
+	expectContainsFalse(t, buf.String(), "http_req_accept")
+}
+
+func TestRequestHeaderPrefix(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:              logger,
+		RequestHeaders:      []string{"Accept"},
+		RequestHeaderPrefix: "header.",
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Add("Accept", "text/html")
+	req.Header.Add("Accept", "application/json")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "header.accept=\"text/html, application/json\"")
+}
+
+func TestResponseHeaders(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:          logger,
+		ResponseHeaders: []string{"Cache-Control", "X-Cache", "Content-Type"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		// Headers set after WriteHeader are not sent, so they must not be logged.
+		w.Header().Set("X-Cache", "HIT")
+		w.Write([]byte("bar"))
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_resp_cache_control=no-store")
+	expectContainsTrue(t, buf.String(), "http_resp_content_type=text/plain")
+	expectContainsFalse(t, buf.String(), "http_resp_x_cache")
+}
+
+func TestResponseHeadersImplicit(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:               logger,
+		ResponseHeaders:      []string{"X-Cache"},
+		ResponseHeaderPrefix: "resp.",
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Cache", "MISS")
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "resp.x_cache=MISS")
+}
+
+func TestRedactedHeaders(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:          logger,
+		RequestHeaders:  []string{"Authorization", "X-Session", "Accept"},
+		ResponseHeaders: []string{"Set-Cookie"},
+		RedactedHeaders: []string{"x-session"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Session", "secret")
+	req.Header.Set("Accept", "text/html")
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_req_authorization=REDACTED")
+	expectContainsTrue(t, buf.String(), "http_req_x_session=REDACTED")
+	expectContainsTrue(t, buf.String(), "http_req_accept=text/html")
+	expectContainsTrue(t, buf.String(), "http_resp_set_cookie=REDACTED")
+	expectContainsFalse(t, buf.String(), "secret")
+}
+
+func TestHeaderRedactor(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:               logger,
+		RequestHeaders:       []string{"X-Email", "Cookie"},
+		RemoteAddressHeaders: []string{"X-Real-IP"},
+		HeaderRedactor: func(name, value string) string {
+			if name == "X-Email" {
+				return value[:1] + "***"
+			}
+			return value
+		},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Email", "someone@example.com")
+	req.Header.Set("X-Real-IP", "98.76.54.32")
+	req.Header.Set("Cookie", "session=secret")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_req_x_email=\"s***\"")
+	expectContainsTrue(t, buf.String(), "http_req_cookie=REDACTED")
+	expectContainsTrue(t, buf.String(), "http_addr=98.76.54.32")
+}
+
+func TestRefererAndUserAgent(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:       logger,
+		LogReferer:   true,
+		LogUserAgent: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set("User-Agent", "curl/7.64.1")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_referer=\"https://example.com/\"")
+	expectContainsTrue(t, buf.String(), "http_user_agent=curl/7.64.1")
+
+	buf.Reset()
+	req.Header = http.Header{}
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsFalse(t, buf.String(), "http_referer")
+	expectContainsFalse(t, buf.String(), "http_user_agent")
+}
+
+func TestTTFB(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	logger.Formatter = &logrus.JSONFormatter{}
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusEarlyHints)
+		w.Write([]byte("bar"))
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte("baz"))
+	})).ServeHTTP(res, req)
+
+	var entry struct {
+		TTFB     time.Duration `json:"http_ttfb"`
+		Duration time.Duration `json:"http_duration"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.TTFB <= 0 || entry.Duration-entry.TTFB < 10*time.Millisecond {
+		t.Errorf("Expected a time to first byte 10ms shorter than the duration [%v] - Got [%v]", entry.Duration, entry.TTFB)
+	}
+}
+
+/* Test Helpers */
+func expect(t *testing.T, a interface{}, b interface{}) {
+	if a != b {
+		t.Errorf("Expected [%v] (type %v) - Got [%v] (type %v)", b, reflect.TypeOf(b), a, reflect.TypeOf(a))
+	}
+}
+
+func expectContainsTrue(t *testing.T, a, b string) {
+	if !strings.Contains(a, b) {
+		t.Errorf("Expected [%s] to contain [%s]", a, b)
+	}
+}
+
+func expectContainsFalse(t *testing.T, a, b string) {
+	if strings.Contains(a, b) {
+		t.Errorf("Expected [%s] to contain [%s]", a, b)
+	}
+}
+
+func TestOnLog(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+		OnLog: func(entry *logrus.Entry, r *http.Request) {
+			delete(entry.Data, FieldAddr)
+			entry.Data["tenant"] = r.Header.Get("X-Tenant")
+			entry.Level = logrus.WarnLevel
+			entry.Message = "Handled"
+		},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Tenant", "acme")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "level=warning")
+	expectContainsTrue(t, buf.String(), "msg=Handled")
+	expectContainsTrue(t, buf.String(), "tenant=acme")
+	expectContainsFalse(t, buf.String(), "http_addr")
+}
+
+func TestOnLogReplacedFields(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	// Fields OnLog replaces the entry's with are the caller's, and are not recycled once written.
+	owned := logrus.Fields{"tenant": "acme"}
+	l := New(Options{
+		Logger: logger,
+		OnLog: func(entry *logrus.Entry, r *http.Request) {
+			entry.Data = owned
+		},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "tenant=acme")
+	expectContainsFalse(t, buf.String(), "http_method")
+	expect(t, len(owned), 1)
+	expect(t, owned["tenant"], "acme")
+}
+
+func TestFieldsFunc(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	type userKey struct{}
+	l := New(Options{
+		Logger:       logger,
+		CustomFields: logrus.Fields{"shard": "a"},
+		FieldsFunc: func(r *http.Request, status, size int, d time.Duration) logrus.Fields {
+			return logrus.Fields{"user_id": r.Context().Value(userKey{}), "shard": "b", "bytes": size, "ok": status < 400 && d > 0}
+		},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userKey{}, "u42"))
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "user_id=u42")
+	expectContainsTrue(t, buf.String(), "shard=b")
+	expectContainsTrue(t, buf.String(), "bytes=3")
+	expectContainsTrue(t, buf.String(), "ok=true")
+}
+
+func TestErrorLogger(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	errBuf := bytes.NewBufferString("")
+	errLogger := logrus.New()
+	errLogger.SetOutput(errBuf)
+	errLogger.SetFormatter(&logrus.JSONFormatter{})
+
+	l := New(Options{
+		Logger:      logger,
+		ErrorLogger: errLogger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+	l.Handler(myHandlerWithError).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_status=200")
+	expectContainsFalse(t, buf.String(), "http_status=502")
+	expectContainsTrue(t, errBuf.String(), `"http_status":502`)
+	expectContainsFalse(t, errBuf.String(), `"http_status":200`)
+}
+
+func TestTeeLoggers(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	jsonBuf := bytes.NewBufferString("")
+	jsonLogger := logrus.New()
+	jsonLogger.SetOutput(jsonBuf)
+	jsonLogger.SetFormatter(&logrus.JSONFormatter{})
+	warnBuf := bytes.NewBufferString("")
+	warnLogger := logrus.New()
+	warnLogger.SetOutput(warnBuf)
+	warnLogger.SetLevel(logrus.WarnLevel)
+
+	l := New(Options{
+		Logger:     logger,
+		TeeLoggers: []*logrus.Logger{jsonLogger, warnLogger},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_status=200")
+	expectContainsTrue(t, jsonBuf.String(), `"http_status":200`)
+	expect(t, warnBuf.String(), "")
+}
+
+func TestStatusMessages(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:         logger,
+		StatusMessages: map[int]string{4: "Client error", 5: "Request failed"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandlerWithError).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), `msg="Request failed"`)
+
+	buf.Reset()
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), `msg="Request received"`)
+}
+
+func TestLogRequestStart(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:             logger,
+		LogRequestStart:    true,
+		RequestIDHeader:    "X-Request-ID",
+		IgnoredRequestURIs: []string{"/healthz"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RequestURI = "/foo"
+	req.Header.Set("X-Request-ID", "abc123")
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expectContainsTrue(t, buf.String(), `msg="Request started"`)
+		expectContainsTrue(t, buf.String(), "http_request_id=abc123")
+		expectContainsFalse(t, buf.String(), "http_status")
+		w.Write([]byte("bar"))
+	})).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), `msg="Request received"`)
+
+	buf.Reset()
+	req, _ = http.NewRequest("GET", "/healthz", nil)
+	req.RequestURI = "/healthz"
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expect(t, buf.String(), "")
+}
+
+func TestClientDisconnected(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req = req.WithContext(ctx)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-r.Context().Done()
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_client_disconnected=true")
+	expectContainsFalse(t, buf.String(), "http_cancel_cause")
+
+	buf.Reset()
+	l.Handler(myHandler).ServeHTTP(res, req.WithContext(context.Background()))
+	expectContainsFalse(t, buf.String(), "http_client_disconnected")
+}
+
+func TestLogContentType(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:         logger,
+		LogContentType: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte("{}"))
+		w.Header().Set("Content-Encoding", "br")
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_content_type=application/json")
+	expectContainsTrue(t, buf.String(), "http_content_encoding=gzip")
+
+	buf.Reset()
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	expectContainsFalse(t, buf.String(), "http_content_type")
+	expectContainsFalse(t, buf.String(), "http_content_encoding")
+}
+
+func TestLengthMismatch(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:        logger,
+		OmittedFields: OmitSize,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "10")
+		w.Write([]byte("bar"))
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_length_mismatch=3")
+	expectContainsTrue(t, buf.String(), "http_content_length=10")
+	expectContainsFalse(t, buf.String(), "http_size")
+
+	buf.Reset()
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "3")
+		w.Write([]byte("bar"))
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	expectContainsFalse(t, buf.String(), "http_length_mismatch")
+
+	buf.Reset()
+	req.Method = "HEAD"
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "10")
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	expectContainsFalse(t, buf.String(), "http_length_mismatch")
+}
+
+func TestDuplicateWriteHeader(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("bar"))
+		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadGateway)
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_status=200")
+	expectContainsTrue(t, buf.String(), "http_duplicate_writeheader=true")
+	expectContainsTrue(t, buf.String(), "http_duplicate_status=500")
+
+	buf.Reset()
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusNoContent)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	expectContainsTrue(t, buf.String(), "http_status=204")
+	expectContainsFalse(t, buf.String(), "http_duplicate_writeheader")
+}
+
+func TestDoubleWrapped(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	h := l.Handler(l.Handler(myHandler))
+	h.ServeHTTP(res, req)
+	h.ServeHTTP(res, req)
+
+	expect(t, strings.Count(buf.String(), "msg=\"Request received\""), 2)
+	expect(t, strings.Count(buf.String(), "applied more than once"), 1)
+	expectContainsTrue(t, buf.String(), "level=warning")
+
+	buf.Reset()
+	other := New(Options{
+		Logger: logger,
+	})
+	l.Handler(other.Handler(myHandler)).ServeHTTP(res, req)
+	expect(t, strings.Count(buf.String(), "msg=\"Request received\""), 2)
+}
+
+func TestResponseTrailers(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:           logger,
+		ResponseTrailers: []string{"Grpc-Status", "grpc-message", "X-Checksum"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/pkg.Service/Method", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		w.Write([]byte("bar"))
+		w.Header().Set("Grpc-Status", "13")
+		w.Header().Set("Grpc-Message", "internal")
+		w.Header().Set(http.TrailerPrefix+"X-Checksum", "abc")
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_status=200")
+	expectContainsTrue(t, buf.String(), "http_trailer_grpc_status=13")
+	expectContainsTrue(t, buf.String(), "http_trailer_grpc_message=internal")
+	expectContainsTrue(t, buf.String(), "http_trailer_x_checksum=abc")
+}
+
+func TestLogBasicAuthUser(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:           logger,
+		LogBasicAuthUser: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.SetBasicAuth("frank", "s3cr3t")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_user=frank")
+	expectContainsFalse(t, buf.String(), "s3cr3t")
+
+	buf.Reset()
+	req.Header.Del("Authorization")
+	l.Handler(myHandler).ServeHTTP(res, req)
+	expectContainsFalse(t, buf.String(), "http_user")
+}
@@ -0,0 +1,287 @@
+/*
+Package logparse parses the entries written by the logger middleware back into Records.
+
+Both the logfmt output of logrus.TextFormatter (with colors disabled, as it is when not writing to a terminal) and the output of logrus.JSONFormatter are supported.
+
+	scanner := logparse.NewScanner(os.Stdin)
+	for scanner.Scan() {
+	    rec := scanner.Record()
+	    fmt.Println(rec.Method, rec.URI, rec.Status, rec.Duration)
+	}
+	if err := scanner.Err(); err != nil {
+	    log.Fatal(err)
+	}
+*/
+package logparse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ant1441/logger-logrus"
+)
+
+// Keys of the fields logrus adds to every entry.
+const (
+	keyTime    = "time"
+	keyLevel   = "level"
+	keyMessage = "msg"
+)
+
+// Record is a parsed log entry.
+type Record struct {
+	Time    time.Time
+	Level   string
+	Message string
+
+	Addr     string
+	Method   string
+	URI      string
+	Path     string
+	Query    string
+	Proto    string
+	Host     string
+	Scheme   string
+	Status   int
+	Size     int
+	Duration time.Duration
+	TTFB     time.Duration
+
+	// Fields holds every field of the entry, including the ones above, in their textual form.
+	Fields map[string]string
+}
+
+// Parser parses log lines written by a middleware configured with FieldNames.
+type Parser struct {
+	// FieldNames is the logger.Options.FieldNames the entries were written with.
+	FieldNames map[string]string
+}
+
+// Parse parses a single log line, in either logfmt or JSON format.
+func Parse(line []byte) (Record, error) {
+	return Parser{}.Parse(line)
+}
+
+// ParseText parses a single logfmt log line, as written by logrus.TextFormatter.
+func ParseText(line []byte) (Record, error) {
+	return Parser{}.ParseText(line)
+}
+
+// ParseJSON parses a single JSON log line, as written by logrus.JSONFormatter.
+func ParseJSON(line []byte) (Record, error) {
+	return Parser{}.ParseJSON(line)
+}
+
+// Parse parses a single log line, in either logfmt or JSON format.
+func (p Parser) Parse(line []byte) (Record, error) {
+	line = bytes.TrimSpace(line)
+	if len(line) > 0 && line[0] == '{' {
+		return p.ParseJSON(line)
+	}
+	return p.ParseText(line)
+}
+
+// ParseText parses a single logfmt log line, as written by logrus.TextFormatter.
+func (p Parser) ParseText(line []byte) (Record, error) {
+	fields := make(map[string]string)
+	s := string(bytes.TrimSpace(line))
+	for len(s) > 0 {
+		eq := indexAny(s, "= ")
+		if eq <= 0 || s[eq] != '=' {
+			return Record{}, fmt.Errorf("logparse: malformed field near %q", s)
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+
+		var val string
+		if len(s) > 0 && s[0] == '"' {
+			end := closingQuote(s)
+			if end < 0 {
+				return Record{}, fmt.Errorf("logparse: unterminated value for %q", key)
+			}
+			unquoted, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return Record{}, fmt.Errorf("logparse: invalid value for %q: %v", key, err)
+			}
+			val = unquoted
+			s = s[end+1:]
+		} else {
+			end := indexAny(s, " ")
+			if end < 0 {
+				end = len(s)
+			}
+			val = s[:end]
+			s = s[end:]
+		}
+		fields[key] = val
+
+		for len(s) > 0 && s[0] == ' ' {
+			s = s[1:]
+		}
+	}
+	return p.newRecord(fields)
+}
+
+// ParseJSON parses a single JSON log line, as written by logrus.JSONFormatter.
+func (p Parser) ParseJSON(line []byte) (Record, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	var raw map[string]interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return Record{}, fmt.Errorf("logparse: %v", err)
+	}
+
+	fields := make(map[string]string, len(raw))
+	for key, val := range raw {
+		switch v := val.(type) {
+		case string:
+			fields[key] = v
+		case json.Number:
+			fields[key] = v.String()
+		case bool:
+			fields[key] = strconv.FormatBool(v)
+		case nil:
+			fields[key] = ""
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return Record{}, fmt.Errorf("logparse: invalid value for %q: %v", key, err)
+			}
+			fields[key] = string(b)
+		}
+	}
+	return p.newRecord(fields)
+}
+
+// key returns the key the field key was written under.
+func (p Parser) key(key string) string {
+	if name, ok := p.FieldNames[key]; ok {
+		return name
+	}
+	return key
+}
+
+func (p Parser) newRecord(fields map[string]string) (Record, error) {
+	rec := Record{
+		Level:   fields[keyLevel],
+		Message: fields[keyMessage],
+		Addr:    fields[p.key(logger.FieldAddr)],
+		Method:  fields[p.key(logger.FieldMethod)],
+		URI:     fields[p.key(logger.FieldURI)],
+		Path:    fields[p.key(logger.FieldPath)],
+		Query:   fields[p.key(logger.FieldQuery)],
+		Proto:   fields[p.key(logger.FieldProto)],
+		Host:    fields[p.key(logger.FieldHost)],
+		Scheme:  fields[p.key(logger.FieldScheme)],
+		Fields:  fields,
+	}
+
+	var err error
+	if val, ok := fields[keyTime]; ok {
+		if rec.Time, err = time.Parse(time.RFC3339Nano, val); err != nil {
+			return Record{}, fmt.Errorf("logparse: invalid %s: %v", keyTime, err)
+		}
+	}
+	if val, ok := fields[p.key(logger.FieldStatus)]; ok {
+		if rec.Status, err = strconv.Atoi(val); err != nil {
+			return Record{}, fmt.Errorf("logparse: invalid %s: %v", logger.FieldStatus, err)
+		}
+	}
+	if val, ok := fields[p.key(logger.FieldSize)]; ok {
+		if rec.Size, err = strconv.Atoi(val); err != nil {
+			return Record{}, fmt.Errorf("logparse: invalid %s: %v", logger.FieldSize, err)
+		}
+	}
+	if val, ok := fields[p.key(logger.FieldDuration)]; ok {
+		if rec.Duration, err = parseDuration(val); err != nil {
+			return Record{}, fmt.Errorf("logparse: invalid %s: %v", logger.FieldDuration, err)
+		}
+	}
+	if val, ok := fields[p.key(logger.FieldTTFB)]; ok {
+		if rec.TTFB, err = parseDuration(val); err != nil {
+			return Record{}, fmt.Errorf("logparse: invalid %s: %v", logger.FieldTTFB, err)
+		}
+	}
+	return rec, nil
+}
+
+// parseDuration parses either a Go duration string, as written by logrus.TextFormatter, or a number of nanoseconds, as written by logrus.JSONFormatter.
+func parseDuration(val string) (time.Duration, error) {
+	if ns, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.Duration(ns), nil
+	}
+	return time.ParseDuration(val)
+}
+
+// indexAny returns the index of the first byte of s in chars, or -1.
+func indexAny(s, chars string) int {
+	for i := 0; i < len(s); i++ {
+		for j := 0; j < len(chars); j++ {
+			if s[i] == chars[j] {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// closingQuote returns the index of the quote closing the quoted string s starts with, or -1.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// Scanner reads Records from an io.Reader, one log line at a time.
+type Scanner struct {
+	// Parser parses the lines read. It may be set before the first call to Scan.
+	Parser Parser
+
+	lines *bufio.Scanner
+	rec   Record
+	err   error
+}
+
+// NewScanner returns a new Scanner reading from r.
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{lines: bufio.NewScanner(r)}
+}
+
+// Scan advances the Scanner to the next Record, skipping blank lines. It returns false when the input is exhausted or a line fails to parse.
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	for s.lines.Scan() {
+		line := s.lines.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		s.rec, s.err = s.Parser.Parse(line)
+		return s.err == nil
+	}
+	s.err = s.lines.Err()
+	return false
+}
+
+// Record returns the Record parsed by the last call to Scan.
+func (s *Scanner) Record() Record {
+	return s.rec
+}
+
+// Err returns the first error encountered by the Scanner.
+func (s *Scanner) Err() error {
+	return s.err
+}
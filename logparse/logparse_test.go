@@ -0,0 +1,159 @@
+package logparse
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ant1441/logger-logrus"
+	"github.com/sirupsen/logrus"
+)
+
+var myHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte("bar"))
+})
+
+func serve(formatter logrus.Formatter) *bytes.Buffer {
+	buf := bytes.NewBufferString("")
+	log := logrus.New()
+	log.SetOutput(buf)
+	log.Formatter = formatter
+
+	l := logger.New(logger.Options{
+		Logger:       log,
+		Message:      "some \"quoted\" message",
+		CustomFields: logrus.Fields{"foo": "bar baz"},
+	})
+
+	res := httptest.NewRecorder()
+	url := "/foo/wow?q=search-term&print=1"
+	req, _ := http.NewRequest("POST", "http://example.com"+url, nil)
+	req.RequestURI = url
+	req.RemoteAddr = "8.8.4.4:1234"
+	l.Handler(myHandler).ServeHTTP(res, req)
+	return buf
+}
+
+func expectRecord(t *testing.T, rec Record) {
+	expect(t, rec.Level, "info")
+	expect(t, rec.Message, "some \"quoted\" message")
+	expect(t, rec.Addr, "8.8.4.4:1234")
+	expect(t, rec.Method, "POST")
+	expect(t, rec.URI, "/foo/wow?q=search-term&print=1")
+	expect(t, rec.Proto, "HTTP/1.1")
+	expect(t, rec.Host, "example.com")
+	expect(t, rec.Scheme, "http")
+	expect(t, rec.Status, http.StatusCreated)
+	expect(t, rec.Size, 3)
+	expect(t, rec.Fields["foo"], "bar baz")
+	if rec.Duration <= 0 {
+		t.Errorf("Expected a positive duration - Got [%v]", rec.Duration)
+	}
+	if rec.TTFB <= 0 || rec.TTFB > rec.Duration {
+		t.Errorf("Expected a time to first byte within the duration [%v] - Got [%v]", rec.Duration, rec.TTFB)
+	}
+	if time.Since(rec.Time) > time.Minute {
+		t.Errorf("Expected a recent time - Got [%v]", rec.Time)
+	}
+}
+
+func TestParseText(t *testing.T) {
+	buf := serve(&logrus.TextFormatter{DisableColors: true})
+
+	rec, err := Parse(buf.Bytes())
+	expect(t, err, nil)
+	expectRecord(t, rec)
+}
+
+func TestParseJSON(t *testing.T) {
+	buf := serve(&logrus.JSONFormatter{})
+
+	rec, err := Parse(buf.Bytes())
+	expect(t, err, nil)
+	expectRecord(t, rec)
+}
+
+func TestParseSplitURI(t *testing.T) {
+	rec, err := Parse([]byte(`level=info http_path=/foo http_query="q=1&p=2"`))
+	expect(t, err, nil)
+	expect(t, rec.URI, "")
+	expect(t, rec.Path, "/foo")
+	expect(t, rec.Query, "q=1&p=2")
+}
+
+func TestParserFieldNames(t *testing.T) {
+	names := map[string]string{logger.FieldStatus: "status", logger.FieldURI: "url.path"}
+
+	buf := bytes.NewBufferString("")
+	log := logrus.New()
+	log.SetOutput(buf)
+	l := logger.New(logger.Options{Logger: log, FieldNames: names})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RequestURI = "/foo"
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	rec, err := Parser{FieldNames: names}.Parse(buf.Bytes())
+	expect(t, err, nil)
+	expect(t, rec.Status, http.StatusCreated)
+	expect(t, rec.URI, "/foo")
+	expect(t, rec.Method, "GET")
+
+	s := NewScanner(bytes.NewReader(buf.Bytes()))
+	s.Parser.FieldNames = names
+	expect(t, s.Scan(), true)
+	expect(t, s.Record().Status, http.StatusCreated)
+}
+
+func TestParseMalformed(t *testing.T) {
+	for _, line := range []string{
+		`level=info msg="unterminated`,
+		`level=info novalue`,
+		`level=info http_status=abc`,
+		`{"level": "info"`,
+	} {
+		if _, err := Parse([]byte(line)); err == nil {
+			t.Errorf("Expected an error parsing [%s]", line)
+		}
+	}
+}
+
+func TestScanner(t *testing.T) {
+	input := strings.Join([]string{
+		`level=info msg=a http_status=200`,
+		``,
+		`{"level":"info","msg":"b","http_status":404}`,
+	}, "\n")
+
+	var msgs []string
+	var statuses []int
+	s := NewScanner(strings.NewReader(input))
+	for s.Scan() {
+		msgs = append(msgs, s.Record().Message)
+		statuses = append(statuses, s.Record().Status)
+	}
+	expect(t, s.Err(), nil)
+	expect(t, reflect.DeepEqual(msgs, []string{"a", "b"}), true)
+	expect(t, reflect.DeepEqual(statuses, []int{200, 404}), true)
+}
+
+func TestScannerError(t *testing.T) {
+	s := NewScanner(strings.NewReader("level=info http_size=x\nlevel=info"))
+	expect(t, s.Scan(), false)
+	if s.Err() == nil {
+		t.Errorf("Expected an error")
+	}
+}
+
+/* Test Helpers */
+func expect(t *testing.T, a interface{}, b interface{}) {
+	if a != b {
+		t.Errorf("Expected [%v] (type %v) - Got [%v] (type %v)", b, reflect.TypeOf(b), a, reflect.TypeOf(a))
+	}
+}
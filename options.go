@@ -0,0 +1,233 @@
+package logger
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// mergeOptions merges opts into a single Options. Every non-zero field of an Options overrides the same field of the Options before it, so later values win, but a zero value, such as false or an empty slice, never overrides a non-zero one.
+func mergeOptions(opts ...Options) Options {
+	var o Options
+	merged := reflect.ValueOf(&o).Elem()
+	for _, opt := range opts {
+		v := reflect.ValueOf(opt)
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Field(i); !field.IsZero() {
+				merged.Field(i).Set(field)
+			}
+		}
+	}
+	return o
+}
+
+// NewWithValidation is like New, but returns an error instead of a Logger when the merged Options are misconfigured, such as an unknown Preset, a SuccessSampleRate outside [0, 1] or a malformed TrustedProxies network, so that mistakes surface at startup rather than as surprising entries.
+func NewWithValidation(opts ...Options) (*Logger, error) {
+	o := mergeOptions(opts...)
+	if err := validateOptions(o); err != nil {
+		return nil, err
+	}
+	return New(o), nil
+}
+
+// validateOptions returns an error describing the first misconfigured field of o, if any.
+func validateOptions(o Options) error {
+	preset := o.Preset
+	if len(preset) == 0 {
+		preset = os.Getenv(PresetEnv)
+	}
+	if _, ok := presets[preset]; !ok && len(preset) > 0 {
+		return fmt.Errorf("logger: unknown preset %q, expected one of %s", preset, strings.Join(Presets(), ", "))
+	}
+
+	for _, nets := range []struct {
+		name string
+		nets []net.IPNet
+	}{
+		{"TrustedProxies", o.TrustedProxies},
+		{"InternalNetworks", o.InternalNetworks},
+		{"DebugNetworks", o.DebugNetworks},
+	} {
+		for _, n := range nets.nets {
+			// As in net.IPNet, an IPv4 address in 16-byte form goes with a 4-byte mask.
+			ip := n.IP
+			if len(n.Mask) == net.IPv4len {
+				if ip4 := ip.To4(); ip4 != nil {
+					ip = ip4
+				}
+			}
+			if ip == nil || len(ip) != len(n.Mask) {
+				return fmt.Errorf("logger: invalid %s network %q", nets.name, n.String())
+			}
+		}
+	}
+
+	if o.SuccessSampleRate < 0 || o.SuccessSampleRate > 1 {
+		return fmt.Errorf("logger: invalid SuccessSampleRate %v, expected a fraction between 0 and 1", o.SuccessSampleRate)
+	}
+	for _, field := range []struct {
+		name     string
+		negative bool
+	}{
+		{"ErrorBodySize", o.ErrorBodySize < 0},
+		{"ReplayCacheSize", o.ReplayCacheSize < 0},
+		{"GeoCacheSize", o.GeoCacheSize < 0},
+		{"ReverseDNSTimeout", o.ReverseDNSTimeout < 0},
+		{"ReverseDNSCacheSize", o.ReverseDNSCacheSize < 0},
+		{"RateLimit", o.RateLimit < 0},
+		{"RateLimitBurst", o.RateLimitBurst < 0},
+		{"RateLimitSummaryInterval", o.RateLimitSummaryInterval < 0},
+		{"AsyncQueueSize", o.AsyncQueueSize < 0},
+		{"AsyncWorkers", o.AsyncWorkers < 0},
+		{"AsyncEntryTimeout", o.AsyncEntryTimeout < 0},
+		{"SlowRequestThreshold", o.SlowRequestThreshold < 0},
+		{"ProbeTimeout", o.ProbeTimeout < 0},
+		{"ArrivalRateWindow", o.ArrivalRateWindow < 0},
+		{"DebugBodySize", o.DebugBodySize < 0},
+	} {
+		if field.negative {
+			return fmt.Errorf("logger: invalid %s, expected a positive value", field.name)
+		}
+	}
+	if o.AsyncWorkers > 0 && o.AsyncQueueSize == 0 {
+		return fmt.Errorf("logger: AsyncWorkers requires AsyncQueueSize")
+	}
+	if o.AsyncEntryTimeout > 0 && o.AsyncQueueSize == 0 {
+		return fmt.Errorf("logger: AsyncEntryTimeout requires AsyncQueueSize")
+	}
+	if o.AsyncSync != nil && o.AsyncQueueSize == 0 {
+		return fmt.Errorf("logger: AsyncSync requires AsyncQueueSize")
+	}
+	if o.AccessLogOnly && o.AccessLog == nil {
+		return fmt.Errorf("logger: AccessLogOnly requires AccessLog")
+	}
+
+	if o.ForwardedStrategy < ForwardedRaw || o.ForwardedStrategy > ForwardedRightmostUntrusted {
+		return fmt.Errorf("logger: invalid ForwardedStrategy %d", o.ForwardedStrategy)
+	}
+	if o.FieldSet < FieldSetStandard || o.FieldSet > FieldSetVerbose {
+		return fmt.Errorf("logger: invalid FieldSet %d", o.FieldSet)
+	}
+	if o.OmittedFields&^allStandardFields != 0 {
+		return fmt.Errorf("logger: invalid OmittedFields %#x", o.OmittedFields)
+	}
+	if o.AccessLogFormat < AccessLogCombined || o.AccessLogFormat > AccessLogALB {
+		return fmt.Errorf("logger: invalid AccessLogFormat %d", o.AccessLogFormat)
+	}
+	if o.URIFields < URICombined || o.URIFields > URIBoth {
+		return fmt.Errorf("logger: invalid URIFields %d", o.URIFields)
+	}
+	if o.DurationFormat < DurationString || o.DurationFormat > DurationMillisecondsFloat {
+		return fmt.Errorf("logger: invalid DurationFormat %d", o.DurationFormat)
+	}
+	if o.AsyncQueueFull < AsyncBlock || o.AsyncQueueFull > AsyncDrop {
+		return fmt.Errorf("logger: invalid AsyncQueueFull %d", o.AsyncQueueFull)
+	}
+
+	for _, status := range o.IgnoredStatusCodes {
+		if status < 100 || status > 999 {
+			return fmt.Errorf("logger: invalid IgnoredStatusCodes status %d", status)
+		}
+	}
+	for class := range o.StatusMessages {
+		if class < 1 || class > 9 {
+			return fmt.Errorf("logger: invalid StatusMessages status class %d", class)
+		}
+	}
+	for _, headers := range []struct {
+		name    string
+		headers []string
+	}{
+		{"RemoteAddressHeaders", o.RemoteAddressHeaders},
+		{"RequestHeaders", o.RequestHeaders},
+		{"ResponseHeaders", o.ResponseHeaders},
+		{"ResponseTrailers", o.ResponseTrailers},
+		{"RedactedHeaders", o.RedactedHeaders},
+	} {
+		for _, header := range headers.headers {
+			if len(strings.TrimSpace(header)) == 0 {
+				return fmt.Errorf("logger: empty %s header", headers.name)
+			}
+		}
+	}
+	for _, path := range o.ProbePaths {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("logger: invalid ProbePaths path %q, expected an absolute path", path)
+		}
+	}
+	return nil
+}
+
+// liveLogger holds the Logger serving requests.
+type liveLogger struct {
+	// mu serializes SetOptions and UpdateOptions.
+	mu      sync.Mutex
+	current atomic.Value
+	// nested warns once about the middleware being applied twice in a chain.
+	nested sync.Once
+}
+
+// current returns the Logger serving requests in place of l: l itself, or its copy reconfigured by the last SetOptions.
+func (l *Logger) current() *Logger {
+	return l.live.current.Load().(*Logger)
+}
+
+// Options returns the Options the Logger currently serves requests with, defaults included.
+func (l *Logger) Options() Options {
+	return l.current().opt
+}
+
+// SetOptions atomically replaces the Options of the Logger, e.g. its ignore lists, SuccessSampleRate or LevelByStatus, so that logging can be reconfigured without a restart. Requests being served keep the Options they started with. Several Options are merged as in New. The options setting up background state (TrackReplays, ReplayCacheSize, ReverseDNS, ReverseDNSTimeout, ReverseDNSCacheSize, GeoResolver, GeoCacheSize, ArrivalRateWindow, RateLimit, RateLimitBurst, RateLimitSummaryInterval, AsyncQueueSize, AsyncWorkers, AsyncEntryTimeout, ProbePaths and ProbeTimeout) keep the values the Logger was created with.
+func (l *Logger) SetOptions(opts ...Options) {
+	l.live.mu.Lock()
+	defer l.live.mu.Unlock()
+
+	l.setOptions(mergeOptions(opts...))
+}
+
+// UpdateOptions atomically reconfigures the Logger as SetOptions does, with the Options modified by fn. fn is given a copy of the current Options, whose slices and maps it must replace rather than modify in place, as requests being served still read them.
+func (l *Logger) UpdateOptions(fn func(o *Options)) {
+	l.live.mu.Lock()
+	defer l.live.mu.Unlock()
+
+	o := l.current().opt
+	fn(&o)
+	l.setOptions(o)
+}
+
+func (l *Logger) setOptions(o Options) {
+	cur := l.current()
+	o.TrackReplays = cur.opt.TrackReplays
+	o.ReplayCacheSize = cur.opt.ReplayCacheSize
+	o.ReverseDNS = cur.opt.ReverseDNS
+	o.ReverseDNSTimeout = cur.opt.ReverseDNSTimeout
+	o.ReverseDNSCacheSize = cur.opt.ReverseDNSCacheSize
+	o.GeoResolver = cur.opt.GeoResolver
+	o.GeoCacheSize = cur.opt.GeoCacheSize
+	o.ArrivalRateWindow = cur.opt.ArrivalRateWindow
+	o.RateLimit = cur.opt.RateLimit
+	o.RateLimitBurst = cur.opt.RateLimitBurst
+	o.RateLimitSummaryInterval = cur.opt.RateLimitSummaryInterval
+	o.AsyncQueueSize = cur.opt.AsyncQueueSize
+	o.AsyncWorkers = cur.opt.AsyncWorkers
+	o.AsyncEntryTimeout = cur.opt.AsyncEntryTimeout
+	o.ProbePaths = cur.opt.ProbePaths
+	o.ProbeTimeout = cur.opt.ProbeTimeout
+
+	next := newLogger(o)
+	next.replays = cur.replays
+	next.rdns = cur.rdns
+	next.geo = cur.geo
+	next.probes = cur.probes
+	next.arrivals = cur.arrivals
+	next.limiter = cur.limiter
+	next.async = cur.async
+	next.accessLogMu = cur.accessLogMu
+	next.random = cur.random
+	next.live = cur.live
+	l.live.current.Store(next)
+}
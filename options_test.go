@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMergeOptions(t *testing.T) {
+	o := mergeOptions(
+		Options{Message: "first", LogReferer: true, RequestHeaders: []string{"Accept"}},
+		Options{Message: "second", ErrorBodySize: 512},
+		Options{CustomFields: logrus.Fields{"app": "myapp"}},
+	)
+
+	expect(t, o.Message, "second")
+	expect(t, o.LogReferer, true)
+	expect(t, len(o.RequestHeaders), 1)
+	expect(t, o.ErrorBodySize, 512)
+	expect(t, o.CustomFields["app"], "myapp")
+	expect(t, mergeOptions().Message, "")
+}
+
+func TestNewMultipleOptions(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:       logger,
+		LogUserAgent: true,
+	}, Options{
+		Message: "Served",
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("User-Agent", "curl/8.4.0")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "msg=Served")
+	expectContainsTrue(t, buf.String(), "http_user_agent=curl/8.4.0")
+}
+
+func TestNewWithValidation(t *testing.T) {
+	l, err := NewWithValidation(Options{
+		Logger:            logrus.New(),
+		Preset:            "verbose",
+		SuccessSampleRate: 0.5,
+		TrustedProxies:    []net.IPNet{{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)}},
+	})
+	expect(t, err, nil)
+	expect(t, l.opt.ErrorBodySize, 1024)
+
+	// IPv4 addresses in 16-byte form, as net.ParseIP returns them, go with 4-byte masks.
+	_, err = NewWithValidation(Options{
+		Logger:         logrus.New(),
+		TrustedProxies: []net.IPNet{{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(8, 32)}},
+	})
+	expect(t, err, nil)
+
+	for o, msg := range map[*Options]string{
+		{Preset: "nope"}:                                             `logger: unknown preset "nope"`,
+		{SuccessSampleRate: 1.5}:                                     "logger: invalid SuccessSampleRate 1.5",
+		{RateLimit: -1}:                                              "logger: invalid RateLimit",
+		{AsyncWorkers: 2}:                                            "logger: AsyncWorkers requires AsyncQueueSize",
+		{AsyncEntryTimeout: time.Second}:                             "logger: AsyncEntryTimeout requires AsyncQueueSize",
+		{AsyncSync: AsyncSyncCritical}:                               "logger: AsyncSync requires AsyncQueueSize",
+		{URIFields: URIFields(7)}:                                    "logger: invalid URIFields 7",
+		{AccessLogOnly: true}:                                        "logger: AccessLogOnly requires AccessLog",
+		{FieldSet: FieldSet(-1)}:                                     "logger: invalid FieldSet -1",
+		{OmittedFields: 1 << 20}:                                     "logger: invalid OmittedFields 0x100000",
+		{StatusMessages: map[int]string{500: "Failed"}}:              "logger: invalid StatusMessages status class 500",
+		{IgnoredStatusCodes: []int{20}}:                              "logger: invalid IgnoredStatusCodes status 20",
+		{RequestHeaders: []string{""}}:                               "logger: empty RequestHeaders header",
+		{ProbePaths: []string{"healthz"}}:                            `logger: invalid ProbePaths path "healthz"`,
+		{TrustedProxies: []net.IPNet{{IP: net.IPv4zero}}}:            "logger: invalid TrustedProxies network",
+		{InternalNetworks: []net.IPNet{{Mask: net.CIDRMask(8, 32)}}}: "logger: invalid InternalNetworks network",
+	} {
+		l, err := NewWithValidation(*o)
+		if l != nil || err == nil {
+			t.Errorf("Expected an error for %+v", *o)
+			continue
+		}
+		expectContainsTrue(t, err.Error(), msg)
+	}
+}
+
+func TestSetOptions(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:       logger,
+		TrackReplays: true,
+	})
+	handler := l.Handler(myHandler)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RequestURI = "/foo"
+	req.Header.Set("X-Request-ID", "abc")
+	handler.ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "http_request_id=abc")
+
+	// Handlers created before SetOptions use the new Options, and the replay cache is kept.
+	l.SetOptions(Options{
+		Logger:  logger,
+		Message: "Served",
+	})
+	buf.Reset()
+	handler.ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "msg=Served")
+	expectContainsTrue(t, buf.String(), "replay_seq=2")
+	expect(t, l.Options().TrackReplays, true)
+
+	l.UpdateOptions(func(o *Options) {
+		o.IgnoredRequestURIs = append([]string{"/foo"}, o.IgnoredRequestURIs...)
+	})
+	buf.Reset()
+	handler.ServeHTTP(res, req)
+	expect(t, buf.String(), "")
+	expect(t, l.Options().Message, "Served")
+}
+
+func TestSetOptionsConcurrent(t *testing.T) {
+	l := New(Options{
+		Logger: logrus.New(),
+	})
+	l.opt.Logger.SetOutput(bytes.NewBufferString(""))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				l.UpdateOptions(func(o *Options) {
+					o.SuccessSampleRate = 1 - o.SuccessSampleRate
+				})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				res := httptest.NewRecorder()
+				req, _ := http.NewRequest("GET", "/foo", nil)
+				l.Handler(myHandler).ServeHTTP(res, req)
+			}
+		}()
+	}
+	wg.Wait()
+	expect(t, l.Options().SuccessSampleRate, float64(0))
+}
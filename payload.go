@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PayloadEncoder encodes captured payloads, such as `http_error_body`, before they are logged, e.g. to compress them.
+type PayloadEncoder interface {
+	// Encoding names the encoding, and is logged alongside the encoded payload.
+	Encoding() string
+	// Encode returns the encoded form of payload.
+	Encode(payload []byte) (string, error)
+}
+
+// GzipBase64 is a PayloadEncoder compressing payloads with gzip, and encoding the result in standard base64.
+var GzipBase64 PayloadEncoder = gzipBase64{}
+
+type gzipBase64 struct{}
+
+func (gzipBase64) Encoding() string {
+	return "gzip+base64"
+}
+
+func (gzipBase64) Encode(payload []byte) (string, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(payload); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// payloadFields adds the captured payload to fields under key, encoded with the PayloadEncoder if any, in which case the encoding and the original size are added as `<key>_encoding` and `<key>_size`. The payload is logged verbatim when it fails to encode.
+func (l *Logger) payloadFields(fields logrus.Fields, key string, payload []byte) {
+	if l.opt.PayloadEncoder != nil {
+		if encoded, err := l.opt.PayloadEncoder.Encode(payload); err == nil {
+			fields[key] = encoded
+			fields[key+"_encoding"] = l.opt.PayloadEncoder.Encoding()
+			fields[key+"_size"] = len(payload)
+			return
+		}
+	}
+	fields[key] = string(payload)
+}
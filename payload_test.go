@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+type failingEncoder struct{}
+
+func (failingEncoder) Encoding() string { return "failing" }
+
+func (failingEncoder) Encode(payload []byte) (string, error) { return "", errors.New("failed") }
+
+func TestGzipBase64(t *testing.T) {
+	encoded, err := GzipBase64.Encode([]byte("some payload"))
+	expect(t, err, nil)
+
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	expect(t, err, nil)
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	expect(t, err, nil)
+	payload, err := ioutil.ReadAll(zr)
+	expect(t, err, nil)
+	expect(t, string(payload), "some payload")
+}
+
+func TestPayloadEncoder(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:         logger,
+		ErrorBodySize:  1024,
+		PayloadEncoder: GzipBase64,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandlerWithError).ServeHTTP(res, req)
+
+	encoded, _ := GzipBase64.Encode([]byte(http.StatusText(http.StatusBadGateway) + "\n"))
+	expectContainsTrue(t, buf.String(), "http_error_body=\""+encoded+"\"")
+	expectContainsTrue(t, buf.String(), "http_error_body_encoding=gzip+base64")
+	expectContainsTrue(t, buf.String(), "http_error_body_size=12")
+}
+
+func TestPayloadEncoderFailure(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:         logger,
+		ErrorBodySize:  3,
+		PayloadEncoder: failingEncoder{},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandlerWithError).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_error_body=Bad")
+	expectContainsFalse(t, buf.String(), "http_error_body_encoding")
+}
@@ -0,0 +1,108 @@
+package logger
+
+import "sort"
+
+// PresetEnv is the environment variable consulted for a preset name when Options.Preset is empty.
+const PresetEnv = "LOGGER_PRESET"
+
+// CDNHeaders are the request headers carrying the CDN identifiers of requests logged by the "cdn" preset: the Cloudflare ray ID, the CloudFront request ID, and the Fastly edge nodes.
+var CDNHeaders = []string{"CF-Ray", "X-Amz-Cf-Id", "Fastly-FF"}
+
+// CDNAddressHeaders are the request headers carrying the client address, which the "cdn" preset looks at as RemoteAddressHeaders when TrustedProxies are set, e.g. to the CDN's published ranges, so that it is logged as `http_addr`, subject to AnonymizeAddr and AddrTransform. Without TrustedProxies any client could claim any address, and they are ignored.
+var CDNAddressHeaders = []string{"CF-Connecting-IP"}
+
+// presets maps a preset name to a function filling in the Options it curates. Preset functions must only set fields that were left at their zero value, so that explicitly given options always win.
+var presets = map[string]func(o *Options){
+	// minimal logs the method, path, status and duration of requests only.
+	"minimal": func(o *Options) {
+		if o.FieldSet == FieldSetStandard {
+			o.FieldSet = FieldSetMinimal
+		}
+	},
+	// verbose logs everything that helps investigating a single request.
+	"verbose": func(o *Options) {
+		if len(o.RequestIDHeader) == 0 {
+			o.RequestIDHeader = "X-Request-ID"
+		}
+		if o.ErrorBodySize == 0 {
+			o.ErrorBodySize = 1024
+		}
+		if o.FieldSet == FieldSetStandard {
+			o.FieldSet = FieldSetVerbose
+		}
+		o.LevelByStatus = true
+	},
+	// security traces retried and replayed requests, and keeps credentials out of the logged URI.
+	"security": func(o *Options) {
+		if len(o.RedactedQueryParams) == 0 {
+			o.RedactedQueryParams = []string{"token", "access_token", "password", "api_key"}
+		}
+		o.TrackReplays = true
+		o.LevelByStatus = true
+	},
+	// ecs targets Elastic Common Schema consumers, logging fields under their ECS names.
+	"ecs": func(o *Options) {
+		if o.FieldNames == nil {
+			o.FieldNames = make(map[string]string, len(ECSFieldNames))
+			for key, name := range ECSFieldNames {
+				o.FieldNames[key] = name
+			}
+		}
+		if o.DurationFormat == DurationString {
+			o.DurationFormat = DurationNanoseconds
+		}
+		o.NormalizeAddr = true
+		if len(o.RequestIDHeader) == 0 {
+			o.RequestIDHeader = "X-Request-ID"
+		}
+		o.LevelByStatus = true
+	},
+	// gcp targets Google Cloud Logging, whose log viewer displays the nested `httpRequest` and correlates traces.
+	"gcp": func(o *Options) {
+		o.CloudLogging = true
+		o.NormalizeAddr = true
+		o.LogReferer = true
+		o.LogUserAgent = true
+		o.LevelByStatus = true
+	},
+	// cdn logs the identifiers CDNs add to the requests they forward, to correlate origin logs with CDN logs.
+	"cdn": func(o *Options) {
+		if len(o.RequestHeaders) == 0 {
+			o.RequestHeaders = append([]string(nil), CDNHeaders...)
+		}
+		if len(o.RemoteAddressHeaders) == 0 && len(o.TrustedProxies) > 0 {
+			o.RemoteAddressHeaders = append([]string(nil), CDNAddressHeaders...)
+		}
+		o.ProxyTimings = true
+	},
+	// dev is meant for local development, where log volume does not matter.
+	"dev": func(o *Options) {
+		if len(o.RequestIDHeader) == 0 {
+			o.RequestIDHeader = "X-Request-ID"
+		}
+		if o.ErrorBodySize == 0 {
+			o.ErrorBodySize = 4096
+		}
+		o.LevelByStatus = true
+	},
+}
+
+// Presets returns the names of the available presets, sorted.
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PresetOptions returns the Options the named preset expands to, and whether the preset exists.
+func PresetOptions(name string) (Options, bool) {
+	var o Options
+	apply, ok := presets[name]
+	if ok {
+		apply(&o)
+	}
+	return o, ok
+}
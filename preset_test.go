@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestPreset(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+		Preset: "verbose",
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	l.Handler(myHandlerWithError).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "level=error")
+	expectContainsTrue(t, buf.String(), "http_request_id=abc123")
+	expectContainsTrue(t, buf.String(), "http_error_body=")
+}
+
+func TestPresetKeepsExplicitOptions(t *testing.T) {
+	o, ok := PresetOptions("dev")
+	expect(t, ok, true)
+	expect(t, o.ErrorBodySize, 4096)
+
+	l := New(Options{
+		Preset:        "dev",
+		ErrorBodySize: 10,
+	})
+	expect(t, l.opt.ErrorBodySize, 10)
+}
+
+func TestPresetFromEnv(t *testing.T) {
+	os.Setenv(PresetEnv, "security")
+	defer os.Unsetenv(PresetEnv)
+
+	l := New()
+	expect(t, l.opt.TrackReplays, true)
+}
+
+func TestUnknownPreset(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	_, ok := PresetOptions("nope")
+	expect(t, ok, false)
+
+	New(Options{
+		Logger: logger,
+		Preset: "nope",
+	})
+	expectContainsTrue(t, buf.String(), "unknown preset")
+}
+
+func TestPresets(t *testing.T) {
+	expect(t, strings.Join(Presets(), ","), "cdn,dev,ecs,gcp,minimal,security,verbose")
+}
+
+func TestPresetECS(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+		Preset: "ecs",
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RequestURI = "/foo"
+	req.RemoteAddr = "10.0.0.1:1234"
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http.request.method=GET")
+	expectContainsTrue(t, buf.String(), "http.response.status_code=200")
+	expectContainsTrue(t, buf.String(), "url.original=/foo")
+	expectContainsTrue(t, buf.String(), "client.ip=10.0.0.1 ")
+	expectContainsTrue(t, buf.String(), "event.duration=")
+	expectContainsFalse(t, buf.String(), "http_method")
+	expectContainsFalse(t, buf.String(), "http_status")
+
+	o, _ := PresetOptions("ecs")
+	o.FieldNames[FieldStatus] = "status"
+	expect(t, ECSFieldNames[FieldStatus], "http.response.status_code")
+}
+
+func TestPresetCDN(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+		Preset: "cdn",
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "198.51.100.5"
+	req.Header.Set("CF-Ray", "8f1b2c3d4e5f6a7b-CDG")
+	req.Header.Set("CF-Connecting-IP", "203.0.113.7")
+	req.Header.Set("X-Amz-Cf-Id", "EXAMPLE2QWPh_KZzJzUi0P4Yax7bJ9t==")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_req_cf_ray=8f1b2c3d4e5f6a7b-CDG")
+	expectContainsTrue(t, buf.String(), "http_req_x_amz_cf_id=")
+	expectContainsFalse(t, buf.String(), "http_req_fastly_ff")
+
+	// Without TrustedProxies, the client address cannot be spoofed by a direct client.
+	expectContainsTrue(t, buf.String(), "http_addr=198.51.100.5")
+	expectContainsFalse(t, buf.String(), "203.0.113.7")
+
+	// Behind a trusted CDN, the client address is anonymized like any other.
+	networks, _ := ParseCIDRs("198.51.100.0/24")
+	buf.Reset()
+	l = New(Options{
+		Logger:         logger,
+		Preset:         "cdn",
+		TrustedProxies: networks,
+		AnonymizeAddr:  true,
+	})
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_addr=203.0.113.0")
+	expectContainsFalse(t, buf.String(), "203.0.113.7")
+	expectContainsFalse(t, buf.String(), "http_req_cf_connecting_ip")
+}
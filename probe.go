@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// probeWatchdog fires when no request has been seen on a probe path for longer than a timeout.
+type probeWatchdog struct {
+	mu       sync.Mutex
+	timeout  time.Duration
+	timers   map[string]*time.Timer
+	lastSeen map[string]time.Time
+	fire     func(path string, lastSeen time.Time)
+}
+
+// newProbeWatchdog starts watching paths, as if each of them had just been seen.
+func newProbeWatchdog(paths []string, timeout time.Duration, fire func(path string, lastSeen time.Time)) *probeWatchdog {
+	w := &probeWatchdog{
+		timeout:  timeout,
+		timers:   make(map[string]*time.Timer, len(paths)),
+		lastSeen: make(map[string]time.Time, len(paths)),
+		fire:     fire,
+	}
+
+	now := time.Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, path := range paths {
+		path := path
+		w.lastSeen[path] = now
+		w.timers[path] = time.AfterFunc(timeout, func() { w.expire(path) })
+	}
+	return w
+}
+
+// seen records a request on path, if it is a probe path.
+func (w *probeWatchdog) seen(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if timer, ok := w.timers[path]; ok {
+		w.lastSeen[path] = time.Now()
+		timer.Reset(w.timeout)
+	}
+}
+
+func (w *probeWatchdog) expire(path string) {
+	w.mu.Lock()
+	lastSeen := w.lastSeen[path]
+	w.mu.Unlock()
+
+	w.fire(path, lastSeen)
+}
+
+// stop stops watching all the probe paths, for good.
+func (w *probeWatchdog) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, timer := range w.timers {
+		timer.Stop()
+	}
+	w.timers = nil
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestProbeMissing(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	missing := make(chan string, 1)
+	l := New(Options{
+		Logger:       logger,
+		ProbePaths:   []string{"/healthz"},
+		ProbeTimeout: 10 * time.Millisecond,
+		OnProbeMissing: func(path string, lastSeen time.Time) {
+			missing <- path
+		},
+	})
+	defer l.Close(context.Background())
+
+	select {
+	case path := <-missing:
+		expect(t, path, "/healthz")
+	case <-time.After(time.Second):
+		t.Fatal("Expected OnProbeMissing to be called")
+	}
+	expectContainsTrue(t, buf.String(), "level=warning")
+	expectContainsTrue(t, buf.String(), "probe_path=/healthz")
+}
+
+func TestProbeSeen(t *testing.T) {
+	missing := make(chan string, 1)
+	l := New(Options{
+		Logger:             logrus.New(),
+		IgnoredRequestURIs: []string{"/healthz"},
+		ProbePaths:         []string{"/healthz"},
+		ProbeTimeout:       200 * time.Millisecond,
+		OnProbeMissing: func(path string, lastSeen time.Time) {
+			missing <- path
+		},
+	})
+	defer l.Close(context.Background())
+
+	// Probes keep arriving, even though they are not logged.
+	deadline := time.Now().Add(400 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/healthz", nil)
+		req.RequestURI = "/healthz"
+		l.Handler(myHandler).ServeHTTP(res, req)
+
+		select {
+		case <-missing:
+			t.Fatal("Expected OnProbeMissing not to be called")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestProbeClosed(t *testing.T) {
+	missing := make(chan string, 1)
+	l := New(Options{
+		Logger:       logrus.New(),
+		ProbePaths:   []string{"/healthz"},
+		ProbeTimeout: 10 * time.Millisecond,
+		OnProbeMissing: func(path string, lastSeen time.Time) {
+			missing <- path
+		},
+	})
+	l.Close(context.Background())
+
+	// Probes arriving after Close do not restart the watchdog.
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	select {
+	case path := <-missing:
+		t.Errorf("Expected OnProbeMissing not to be called - Got [%v]", path)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"os"
+	"runtime/debug"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newCustomFields returns the fields added to every entry by o: the process, service and build fields, renamed as FieldNames says, and CustomFields, which override them.
+func newCustomFields(o Options) logrus.Fields {
+	fields := logrus.Fields{}
+	if o.ProcessFields {
+		if hostname, err := os.Hostname(); err == nil {
+			fields[FieldHostname] = hostname
+		} else {
+			o.Logger.Warnf("logger: failed to get the host name: %v", err)
+		}
+		fields[FieldPID] = os.Getpid()
+	}
+	if len(o.ServiceName) > 0 {
+		fields[FieldServiceName] = o.ServiceName
+	}
+	if len(o.ServiceEnvironment) > 0 {
+		fields[FieldServiceEnvironment] = o.ServiceEnvironment
+	}
+	if o.BuildInfo {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			buildInfoFields(fields, info)
+		}
+	}
+	renameFields(fields, o.FieldNames)
+	for key, val := range o.CustomFields {
+		fields[key] = val
+	}
+	return fields
+}
+
+// buildInfoFields adds the version of the main module of info and its VCS revision, if known, to fields.
+func buildInfoFields(fields logrus.Fields, info *debug.BuildInfo) {
+	if v := info.Main.Version; len(v) > 0 && v != "(devel)" {
+		fields[FieldServiceVersion] = v
+	}
+	if rev := vcsRevision(info); len(rev) > 0 {
+		fields[FieldGitSHA] = rev
+	}
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestProcessFields(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:             logger,
+		ProcessFields:      true,
+		ServiceName:        "checkout",
+		ServiceEnvironment: "production",
+		CustomFields:       logrus.Fields{FieldServiceEnvironment: "staging"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	hostname, _ := os.Hostname()
+	expectContainsTrue(t, buf.String(), "hostname="+hostname)
+	expectContainsTrue(t, buf.String(), "pid="+strconv.Itoa(os.Getpid()))
+	expectContainsTrue(t, buf.String(), "service_name=checkout")
+	expectContainsTrue(t, buf.String(), "service_environment=staging")
+}
+
+func TestProcessFieldsRenamed(t *testing.T) {
+	fields := newCustomFields(Options{
+		Logger:      logrus.New(),
+		ServiceName: "checkout",
+		FieldNames:  ECSFieldNames,
+	})
+
+	expect(t, len(fields), 1)
+	expect(t, fields["service.name"], "checkout")
+}
+
+func TestBuildInfoFields(t *testing.T) {
+	fields := logrus.Fields{}
+	buildInfoFields(fields, &debug.BuildInfo{Main: debug.Module{Path: "example.com/checkout", Version: "v1.2.3"}})
+	expect(t, fields[FieldServiceVersion], "v1.2.3")
+	expect(t, len(fields), 1)
+
+	fields = logrus.Fields{}
+	buildInfoFields(fields, &debug.BuildInfo{Main: debug.Module{Path: "example.com/checkout", Version: "(devel)"}})
+	expect(t, len(fields), 0)
+}
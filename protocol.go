@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// protocolFields adds the protocol details of r to fields: its normalized HTTP version, e.g. "1.1", "2" or "3", and the application protocol negotiated through ALPN, if any.
+func protocolFields(fields logrus.Fields, r *http.Request) {
+	if version := protocolVersion(r); len(version) > 0 {
+		fields[FieldProtoVersion] = version
+	}
+	if r.TLS != nil && len(r.TLS.NegotiatedProtocol) > 0 {
+		fields[FieldTLSProtocol] = r.TLS.NegotiatedProtocol
+	}
+}
+
+// protocolVersion returns the HTTP version of r, "1.0", "1.1", "2" or "3", from its ProtoMajor and ProtoMinor, or from its Proto for servers that do not set them, e.g. "HTTP/3". Empty when it cannot be told.
+func protocolVersion(r *http.Request) string {
+	major, minor := r.ProtoMajor, r.ProtoMinor
+	if major == 0 {
+		v := strings.TrimPrefix(strings.ToUpper(r.Proto), "HTTP/")
+		if v == r.Proto || len(v) == 0 {
+			return ""
+		}
+		m := v
+		if i := strings.IndexByte(v, '.'); i >= 0 {
+			m = v[:i]
+			minor, _ = strconv.Atoi(v[i+1:])
+		}
+		var err error
+		if major, err = strconv.Atoi(m); err != nil {
+			return ""
+		}
+	}
+	if major >= 2 {
+		return strconv.Itoa(major)
+	}
+	return strconv.Itoa(major) + "." + strconv.Itoa(minor)
+}
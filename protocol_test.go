@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestProtocolVersion(t *testing.T) {
+	for proto, version := range map[string]string{
+		"HTTP/1.0": "1.0",
+		"HTTP/1.1": "1.1",
+		"HTTP/2.0": "2",
+		"HTTP/3.0": "3",
+		"HTTP/3":   "3",
+		"h3":       "",
+		"":         "",
+	} {
+		expect(t, protocolVersion(&http.Request{Proto: proto}), version)
+	}
+	expect(t, protocolVersion(&http.Request{Proto: "HTTP/3.0", ProtoMajor: 3}), "3")
+	expect(t, protocolVersion(&http.Request{Proto: "HTTP/1.1", ProtoMajor: 1, ProtoMinor: 1}), "1.1")
+}
+
+func TestLogProtocol(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:      logger,
+		LogProtocol: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+	req.TLS = &tls.ConnectionState{NegotiatedProtocol: "h2"}
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_proto=HTTP/2.0")
+	expectContainsTrue(t, buf.String(), "http_version=2")
+	expectContainsTrue(t, buf.String(), "tls_protocol=h2")
+}
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Headers set by proxies and CDNs that carry timing information.
+const (
+	headerEnvoyUpstreamServiceTime = "X-Envoy-Upstream-Service-Time"
+	headerCFCacheStatus            = "Cf-Cache-Status"
+	headerRequestStart             = "X-Request-Start"
+)
+
+// proxyTimingFields adds the normalized proxy timing fields of the request and its response to fields.
+func (l *Logger) proxyTimingFields(fields logrus.Fields, r *http.Request, crw *customResponseWriter) {
+	upstream := l.responseHeader(crw, headerEnvoyUpstreamServiceTime)
+	if len(upstream) == 0 {
+		upstream = r.Header.Get(headerEnvoyUpstreamServiceTime)
+	}
+	if ms, err := strconv.ParseInt(strings.TrimSpace(upstream), 10, 64); err == nil {
+		fields[FieldProxyUpstreamTime] = ms
+	}
+
+	if start, ok := parseRequestStart(r.Header.Get(headerRequestStart)); ok {
+		queued := crw.start.Sub(start)
+		if queued < 0 {
+			queued = 0
+		}
+		fields[FieldProxyQueueTime] = float64(queued) / float64(time.Millisecond)
+	}
+
+	cache := l.responseHeader(crw, headerCFCacheStatus)
+	if len(cache) == 0 {
+		cache = r.Header.Get(headerCFCacheStatus)
+	}
+	if len(cache) > 0 {
+		fields[FieldCDNCacheStatus] = strings.ToLower(strings.TrimSpace(cache))
+	}
+}
+
+// parseRequestStart parses the time a front proxy received the request from an X-Request-Start header, given either as "t=<seconds>.<fraction>" by nginx, or as "[t=]<integer>" in seconds, milliseconds or microseconds since the epoch.
+func parseRequestStart(header string) (time.Time, bool) {
+	header = strings.TrimPrefix(strings.TrimSpace(header), "t=")
+	if len(header) == 0 {
+		return time.Time{}, false
+	}
+
+	if strings.IndexByte(header, '.') >= 0 {
+		secs, err := strconv.ParseFloat(header, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(0, int64(secs*float64(time.Second))), true
+	}
+
+	n, err := strconv.ParseInt(header, 10, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}, false
+	}
+	switch {
+	case n < 1e11:
+		return time.Unix(n, 0), true
+	case n < 1e14:
+		return time.Unix(0, n*int64(time.Millisecond)), true
+	default:
+		return time.Unix(0, n*int64(time.Microsecond)), true
+	}
+}
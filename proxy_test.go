@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseRequestStart(t *testing.T) {
+	expected := time.Unix(1500000000, 0)
+	for header, start := range map[string]time.Time{
+		"t=1500000000.123": expected.Add(123 * time.Millisecond),
+		"1500000000":       expected,
+		"t=1500000000123":  expected.Add(123 * time.Millisecond),
+		"1500000000123456": expected.Add(123456 * time.Microsecond),
+	} {
+		parsed, ok := parseRequestStart(header)
+		expect(t, ok, true)
+		if d := parsed.Sub(start); d > time.Millisecond || d < -time.Millisecond {
+			t.Errorf("Expected [%s] to parse as [%v] - Got [%v]", header, start, parsed)
+		}
+	}
+
+	for _, header := range []string{"", "t=", "t=abc", "-1"} {
+		if _, ok := parseRequestStart(header); ok {
+			t.Errorf("Expected [%s] to be invalid", header)
+		}
+	}
+}
+
+func TestProxyTimings(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:       logger,
+		ProxyTimings: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	start := time.Now().Add(-50*time.Millisecond).UnixNano() / int64(time.Millisecond)
+	req.Header.Set("X-Request-Start", "t="+strconv.FormatInt(start, 10))
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Envoy-Upstream-Service-Time", "42")
+		w.Header().Set("CF-Cache-Status", "HIT")
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "proxy_upstream_time_ms=42")
+	expectContainsTrue(t, buf.String(), "proxy_queue_time_ms=")
+	expectContainsTrue(t, buf.String(), "cdn_cache_status=hit")
+}
+
+func TestProxyTimingsAbsent(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:       logger,
+		ProxyTimings: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), "proxy_")
+	expectContainsFalse(t, buf.String(), "cdn_")
+}
@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimitByPath is a RateLimitKey limiting the entries logged for each request path.
+func RateLimitByPath(r *http.Request, addr string) string {
+	return r.URL.Path
+}
+
+// RateLimitByClient is a RateLimitKey limiting the entries logged for each client address, as it is logged.
+func RateLimitByClient(r *http.Request, addr string) string {
+	return addr
+}
+
+// rateLimiter is a token bucket per key, limiting the rate of entries logged, which reports the entries it suppressed for each key once every interval.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets *lruCache
+	// suppressed counts the entries suppressed for each key since the last report. At most size keys are counted, the entries of any other key are counted under the empty key.
+	size       int
+	suppressed map[string]int
+	report     func(key string, n int)
+	ticker     *time.Ticker
+	done       chan struct{}
+}
+
+// tokenBucket holds the tokens available to a key, as of last.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter starts a rateLimiter, which calls report with the number of entries suppressed for each key once every interval, if any.
+func newRateLimiter(rate float64, burst int, interval time.Duration, size int, report func(key string, n int)) *rateLimiter {
+	rl := &rateLimiter{
+		rate:       rate,
+		burst:      float64(burst),
+		buckets:    newLRUCache(size),
+		size:       size,
+		suppressed: make(map[string]int),
+		report:     report,
+		ticker:     time.NewTicker(interval),
+		done:       make(chan struct{}),
+	}
+	go rl.run()
+	return rl
+}
+
+func (rl *rateLimiter) run() {
+	for {
+		select {
+		case <-rl.ticker.C:
+			rl.flush()
+		case <-rl.done:
+			return
+		}
+	}
+}
+
+// allow reports whether an entry for key may be logged at now, counting it as suppressed otherwise.
+func (rl *rateLimiter) allow(key string, now time.Time) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	var b *tokenBucket
+	if v, ok := rl.buckets.get(key); ok {
+		b = v.(*tokenBucket)
+		b.tokens += now.Sub(b.last).Seconds() * rl.rate
+		if b.tokens > rl.burst {
+			b.tokens = rl.burst
+		}
+		b.last = now
+	} else {
+		b = &tokenBucket{tokens: rl.burst, last: now}
+		rl.buckets.add(key, b)
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	if _, ok := rl.suppressed[key]; !ok && len(rl.suppressed) >= rl.size {
+		key = ""
+	}
+	rl.suppressed[key]++
+	return false
+}
+
+// flush reports the entries suppressed for each key since the last report, if any.
+func (rl *rateLimiter) flush() {
+	rl.mu.Lock()
+	suppressed := rl.suppressed
+	if len(suppressed) > 0 {
+		rl.suppressed = make(map[string]int)
+	}
+	rl.mu.Unlock()
+
+	for key, n := range suppressed {
+		rl.report(key, n)
+	}
+}
+
+// stop stops the periodic reports for good, once it has reported the entries still pending.
+func (rl *rateLimiter) stop() {
+	rl.mu.Lock()
+	select {
+	case <-rl.done:
+		rl.mu.Unlock()
+		return
+	default:
+		close(rl.done)
+	}
+	rl.mu.Unlock()
+
+	rl.ticker.Stop()
+	rl.flush()
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	summary := make(map[string]int)
+	rl := newRateLimiter(2, 2, time.Hour, 1, func(key string, n int) { summary[key] = n })
+	defer rl.stop()
+
+	expect(t, rl.allow("/foo", now), true)
+	expect(t, rl.allow("/foo", now), true)
+	expect(t, rl.allow("/foo", now), false)
+
+	// Tokens are refilled at the rate.
+	expect(t, rl.allow("/foo", now.Add(500*time.Millisecond)), true)
+	expect(t, rl.allow("/foo", now.Add(500*time.Millisecond)), false)
+
+	// Suppressed entries of keys beyond the size are counted under the empty key.
+	now = now.Add(time.Second)
+	rl.allow("/bar", now)
+	rl.allow("/bar", now)
+	expect(t, rl.allow("/bar", now), false)
+
+	rl.flush()
+	expect(t, len(summary), 2)
+	expect(t, summary["/foo"], 2)
+	expect(t, summary[""], 1)
+
+	summary = make(map[string]int)
+	rl.flush()
+	expect(t, len(summary), 0)
+}
+
+func TestRateLimit(t *testing.T) {
+	buf := &syncBuffer{}
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:                   logger,
+		RateLimit:                1,
+		RateLimitKey:             RateLimitByClient,
+		RateLimitSummaryInterval: 10 * time.Millisecond,
+	})
+	defer l.Close(context.Background())
+
+	res := httptest.NewRecorder()
+	for _, addr := range []string{"192.0.2.1:1234", "192.0.2.1:1234", "192.0.2.2:1234"} {
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		req.RemoteAddr = addr
+		l.Handler(myHandler).ServeHTTP(res, req)
+	}
+
+	expect(t, strings.Count(buf.String(), "Request received"), 2)
+	expectContainsTrue(t, buf.String(), "http_addr=\"192.0.2.1:1234\"")
+	expectContainsTrue(t, buf.String(), "http_addr=\"192.0.2.2:1234\"")
+
+	// The summary is logged once the interval elapses, without any further request.
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "Log entries suppressed") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	expectContainsTrue(t, buf.String(), "msg=\"Log entries suppressed\" rate_limit_key=\"192.0.2.1:1234\" rate_limit_suppressed=1")
+	expect(t, strings.Count(buf.String(), "Log entries suppressed"), 1)
+}
+
+func TestRateLimitClose(t *testing.T) {
+	buf := &syncBuffer{}
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:    logger,
+		RateLimit: 1,
+	})
+
+	res := httptest.NewRecorder()
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		l.Handler(myHandler).ServeHTTP(res, req)
+	}
+	expectContainsFalse(t, buf.String(), "Log entries suppressed")
+
+	// Close logs the entries suppressed since the last summary, and stops the summaries.
+	expect(t, l.Close(context.Background()), nil)
+	expectContainsTrue(t, buf.String(), "msg=\"Log entries suppressed\" rate_limit_key=/foo rate_limit_suppressed=2")
+	expect(t, l.Close(context.Background()), nil)
+	expect(t, strings.Count(buf.String(), "Log entries suppressed"), 1)
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxPendingLookups bounds the reverse DNS lookups in flight, past which addresses are not looked up until a lookup completes.
+const maxPendingLookups = 64
+
+// reverseDNS looks up the PTR records of client addresses in the background, and caches the host names found.
+type reverseDNS struct {
+	mu      sync.Mutex
+	hosts   *lruCache
+	pending map[string]bool
+	timeout time.Duration
+	lookup  func(ctx context.Context, addr string) ([]string, error)
+}
+
+func newReverseDNS(size int, timeout time.Duration) *reverseDNS {
+	return &reverseDNS{
+		hosts:   newLRUCache(size),
+		pending: make(map[string]bool),
+		timeout: timeout,
+		lookup:  net.DefaultResolver.LookupAddr,
+	}
+}
+
+// host returns the host name of ip, if it has been looked up already, and otherwise starts looking it up for the next requests, so that requests never wait on DNS. It returns false if ip has no host name yet.
+func (d *reverseDNS) host(ip net.IP) (string, bool) {
+	key := ip.String()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if v, ok := d.hosts.get(key); ok {
+		host := v.(string)
+		return host, len(host) > 0
+	}
+	if !d.pending[key] && len(d.pending) < maxPendingLookups {
+		d.pending[key] = true
+		go d.resolve(key)
+	}
+	return "", false
+}
+
+// resolve looks up the host name of the address addr and caches it, or an empty one if there is none, so that failed lookups are not retried for every request.
+func (d *reverseDNS) resolve(addr string) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	var host string
+	if names, err := d.lookup(ctx, addr); err == nil && len(names) > 0 {
+		host = strings.TrimSuffix(names[0], ".")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.hosts.add(addr, host)
+	delete(d.pending, addr)
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestReverseDNS(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:     logger,
+		ReverseDNS: true,
+	})
+	expect(t, l.opt.ReverseDNSTimeout, time.Second)
+	expect(t, l.opt.ReverseDNSCacheSize, 10000)
+
+	looked := make(chan string, 2)
+	l.rdns.lookup = func(ctx context.Context, addr string) ([]string, error) {
+		defer func() { looked <- addr }()
+		if addr == "203.0.113.7" {
+			return []string{"host.example.com."}, nil
+		}
+		return nil, fmt.Errorf("no PTR record for %s", addr)
+	}
+
+	serve := func(remoteAddr string) {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		req.RemoteAddr = remoteAddr
+		l.Handler(myHandler).ServeHTTP(res, req)
+	}
+	cached := func(addr string) bool {
+		l.rdns.mu.Lock()
+		defer l.rdns.mu.Unlock()
+		_, ok := l.rdns.hosts.get(addr)
+		return ok
+	}
+
+	// The first request of an address is logged without waiting on the lookup.
+	serve("203.0.113.7:1234")
+	serve("198.51.100.1:1234")
+	expectContainsFalse(t, buf.String(), "http_client_host")
+	for _, addr := range []string{<-looked, <-looked} {
+		for !cached(addr) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	buf.Reset()
+	serve("203.0.113.7:1234")
+	expectContainsTrue(t, buf.String(), "http_client_host=host.example.com")
+
+	buf.Reset()
+	serve("198.51.100.1:1234")
+	expectContainsFalse(t, buf.String(), "http_client_host")
+	select {
+	case addr := <-looked:
+		t.Errorf("%s looked up again", addr)
+	default:
+	}
+
+	// Host names are not logged for anonymized or transformed addresses.
+	for _, update := range []func(o *Options){
+		func(o *Options) { o.AnonymizeAddr = true },
+		func(o *Options) { o.AnonymizeAddr, o.AddrTransform = false, HashIP("salt") },
+	} {
+		l.UpdateOptions(update)
+		buf.Reset()
+		serve("203.0.113.7:1234")
+		expectContainsFalse(t, buf.String(), "http_client_host")
+		expectContainsFalse(t, buf.String(), "host.example.com")
+	}
+}
+
+func TestReverseDNSPending(t *testing.T) {
+	d := newReverseDNS(10, time.Second)
+	release := make(chan struct{})
+	d.lookup = func(ctx context.Context, addr string) ([]string, error) {
+		<-release
+		return nil, nil
+	}
+	defer close(release)
+
+	for i := 0; i < maxPendingLookups+10; i++ {
+		d.host(net.IPv4(10, 0, byte(i>>8), byte(i)))
+	}
+	d.host(net.IPv4(10, 0, 0, 0))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	expect(t, len(d.pending), maxPendingLookups)
+}
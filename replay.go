@@ -0,0 +1,26 @@
+package logger
+
+import "sync"
+
+// replayTracker counts how many times each request ID has been seen.
+type replayTracker struct {
+	mu   sync.Mutex
+	seen *lruCache
+}
+
+func newReplayTracker(size int) *replayTracker {
+	return &replayTracker{seen: newLRUCache(size)}
+}
+
+// observe records an arrival of id and returns its sequence number, starting at 1 for the first arrival.
+func (t *replayTracker) observe(id string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seq := 1
+	if v, ok := t.seen.get(id); ok {
+		seq = v.(int) + 1
+	}
+	t.seen.add(id, seq)
+	return seq
+}
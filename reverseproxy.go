@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"time"
+)
+
+// ReverseProxy instruments p, wrapping its Transport and ErrorHandler, so that the requests it proxies are logged by the Logger middleware wrapping it with the upstream target as `http_upstream`, the time the upstream took to respond with its headers as `http_upstream_duration`, the number of attempts as `http_upstream_attempts`, and the error passed to ErrorHandler, if any, as `http_proxy_error`. Without an ErrorHandler, p responds to errors with a 502 as ReverseProxy does, without logging them through ErrorLog. It returns p.
+func ReverseProxy(p *httputil.ReverseProxy) *httputil.ReverseProxy {
+	base := p.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	p.Transport = upstreamTransport{base}
+
+	next := p.ErrorHandler
+	p.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if state, ok := r.Context().Value(stateKey).(*requestState); ok {
+			state.proxyError = err.Error()
+		}
+		if next != nil {
+			next(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	return p
+}
+
+// upstreamTransport records the upstream target and response time of the requests sent by a ReverseProxy in their request state.
+type upstreamTransport struct {
+	base http.RoundTripper
+}
+
+func (t upstreamTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if state, ok := req.Context().Value(stateKey).(*requestState); ok {
+		state.attempts++
+		state.upstream = req.URL.Scheme + "://" + req.URL.Host
+		state.upstreamDuration = time.Since(start)
+	}
+	return resp, err
+}
+
+// RecordAttempt records an attempt at sending the request carried by ctx to backend, so that it is logged by the Logger middleware with the number of attempts as `http_upstream_attempts` and the backend of the last one as `http_upstream`. It is meant to be called by retrying proxies and transports before each attempt, from the goroutine serving the request, and is a no-op when ctx did not come through the middleware. Attempts made through a ReverseProxy instrumented by ReverseProxy are recorded already.
+func RecordAttempt(ctx context.Context, backend string) {
+	if state, ok := ctx.Value(stateKey).(*requestState); ok {
+		state.attempts++
+		state.upstream = backend
+	}
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestReverseProxy(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	upstream := httptest.NewServer(myHandler)
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+
+	l := New(Options{Logger: logger})
+	proxy := ReverseProxy(httputil.NewSingleHostReverseProxy(target))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RequestURI = "/foo"
+	l.Handler(proxy).ServeHTTP(res, req)
+
+	expect(t, res.Body.String(), "bar")
+	expectContainsTrue(t, buf.String(), "http_upstream=\""+upstream.URL+"\"")
+	expectContainsTrue(t, buf.String(), "http_upstream_duration=")
+	expectContainsTrue(t, buf.String(), "http_upstream_attempts=1")
+	expectContainsFalse(t, buf.String(), "http_proxy_error")
+}
+
+func TestReverseProxyError(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	target, _ := url.Parse("http://upstream.invalid")
+	l := New(Options{Logger: logger})
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+	ReverseProxy(proxy)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(proxy).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusBadGateway)
+	expectContainsTrue(t, buf.String(), "http_status=502")
+	expectContainsTrue(t, buf.String(), "http_upstream=\"http://upstream.invalid\"")
+	expectContainsTrue(t, buf.String(), "http_proxy_error=\"connection refused\"")
+}
+
+func TestRecordAttempt(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{Logger: logger})
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RecordAttempt(r.Context(), "10.0.0.1:8080")
+		RecordAttempt(r.Context(), "10.0.0.2:8080")
+		w.Write([]byte("bar"))
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_upstream_attempts=2")
+	expectContainsTrue(t, buf.String(), "http_upstream=\"10.0.0.2:8080\"")
+	expectContainsFalse(t, buf.String(), "http_upstream_duration")
+
+	// Outside of the middleware, attempts are not recorded.
+	RecordAttempt(req.Context(), "10.0.0.1:8080")
+}
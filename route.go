@@ -0,0 +1,11 @@
+//go:build !go1.23
+// +build !go1.23
+
+package logger
+
+import "net/http"
+
+// routePattern returns the ServeMux pattern that matched r. Requests carry no pattern before Go 1.23, so it is always empty.
+func routePattern(r *http.Request) string {
+	return ""
+}
@@ -0,0 +1,11 @@
+//go:build go1.23
+// +build go1.23
+
+package logger
+
+import "net/http"
+
+// routePattern returns the ServeMux pattern that matched r, e.g. "GET /users/{id}", once r has been served. Empty when r was not routed by a ServeMux.
+func routePattern(r *http.Request) string {
+	return r.Pattern
+}
@@ -0,0 +1,41 @@
+//go:build go1.23
+// +build go1.23
+
+// The module declares an older Go version, under which ServeMux ignores method and wildcard patterns.
+//go:debug httpmuxgo121=0
+
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRoutePattern(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", myHandler)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/42", nil)
+	l.Handler(mux).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), `http_route="GET /users/{id}"`)
+
+	buf.Reset()
+	req, _ = http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), "http_route=")
+}
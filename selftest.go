@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/sirupsen/logrus"
+)
+
+// selfTestCase is a representative request run by SelfTest.
+type selfTestCase struct {
+	name    string
+	method  string
+	uri     string
+	handler http.HandlerFunc
+}
+
+// SelfTest runs representative requests (2xx, 4xx, 5xx, panic, hijack and, if IgnoredRequestURIs is set, ignored path) through a copy of the Logger, and writes each request followed by the resulting entry, if any, to w. It lets operators check redaction, filtering and formatting before deploying a configuration. The Logger itself is left untouched, and nothing is written to its output.
+func (l *Logger) SelfTest(w io.Writer) {
+	l = l.current()
+	counter := &countingWriter{w: w}
+	out := logrus.New()
+	out.Out = counter
+	out.Formatter = l.opt.Logger.Formatter
+	out.Level = l.opt.Logger.Level
+
+	opt := l.opt
+	opt.Logger = out
+	opt.ErrorLogger = nil
+	opt.TeeLoggers = nil
+	opt.ProbePaths = nil
+	opt.AsyncQueueSize = 0
+	if opt.AccessLog != nil {
+		opt.AccessLog = counter
+	}
+	st := New(opt)
+
+	cases := []selfTestCase{
+		{"2xx", "GET", "/selftest?q=term&token=secret", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("ok"))
+		}},
+		{"4xx", "GET", "/selftest/missing", http.NotFound},
+		{"5xx", "POST", "/selftest/error", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+		}},
+		{"panic", "GET", "/selftest/panic", func(w http.ResponseWriter, r *http.Request) {
+			panic("selftest")
+		}},
+		{"hijack", "GET", "/selftest/hijack", func(w http.ResponseWriter, r *http.Request) {
+			conn, rw, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\n")
+			rw.Flush()
+			conn.Close()
+		}},
+	}
+	if len(l.opt.IgnoredRequestURIs) > 0 {
+		cases = append(cases, selfTestCase{"ignored", "GET", l.opt.IgnoredRequestURIs[0], func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}})
+	}
+
+	for _, c := range cases {
+		fmt.Fprintf(w, "# %s: %s %s\n", c.name, c.method, c.uri)
+		written := counter.n
+		if recovered := st.selfTestRequest(c); recovered != nil {
+			fmt.Fprintf(w, "# handler panicked: %v\n", recovered)
+		}
+		if counter.n == written {
+			fmt.Fprintln(w, "# (no entry)")
+		}
+	}
+	if len(l.opt.IgnoredRequestURIs) == 0 {
+		fmt.Fprintln(w, "# (no IgnoredRequestURIs configured)")
+	}
+}
+
+// selfTestRequest serves a synthesized request for c, and returns the value the handler panicked with, if any.
+func (l *Logger) selfTestRequest(c selfTestCase) (recovered interface{}) {
+	defer func() {
+		recovered = recover()
+	}()
+
+	req := httptest.NewRequest(c.method, c.uri, nil)
+	req.RemoteAddr = "192.0.2.1:54321"
+	req.Header.Set("User-Agent", "logger-selftest")
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Cookie", "session=secret")
+	req.Header.Set("X-Request-ID", "selftest-"+c.name)
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 192.0.2.1")
+
+	l.Handler(c.handler).ServeHTTP(&hijackableRecorder{httptest.NewRecorder()}, req)
+	return nil
+}
+
+// hijackableRecorder is an httptest.ResponseRecorder whose connection can be hijacked.
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (h *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	server, client := net.Pipe()
+	go io.Copy(ioutil.Discard, client)
+	return server, bufio.NewReadWriter(bufio.NewReader(server), bufio.NewWriter(server)), nil
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += n
+	return n, err
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSelfTest(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:              logger,
+		IgnoredRequestURIs:  []string{"/healthz"},
+		RedactedQueryParams: []string{"token"},
+		RequestHeaders:      []string{"Authorization"},
+	})
+
+	out := bytes.NewBufferString("")
+	l.SelfTest(out)
+
+	expect(t, buf.String(), "")
+	for _, line := range []string{
+		"# 2xx: GET /selftest?q=term&token=secret\n",
+		"http_status=200",
+		"# 4xx: GET /selftest/missing\n",
+		"http_status=404",
+		"# 5xx: POST /selftest/error\n",
+		"http_status=500",
+		"# panic: GET /selftest/panic\n",
+		"http_panic=selftest",
+		"# handler panicked: selftest\n# hijack",
+		"# hijack: GET /selftest/hijack\n",
+		"# ignored: GET /healthz\n# (no entry)\n",
+		"token=REDACTED",
+		"http_req_authorization=REDACTED",
+	} {
+		expectContainsTrue(t, out.String(), line)
+	}
+	expectContainsFalse(t, out.String(), "secret\"")
+	expectContainsFalse(t, out.String(), "no IgnoredRequestURIs")
+	expect(t, strings.Count(out.String(), "msg=\"Request received\""), 5)
+}
+
+func TestSelfTestWithoutIgnoredRequestURIs(t *testing.T) {
+	l := New(Options{
+		Logger: logrus.New(),
+	})
+
+	out := bytes.NewBufferString("")
+	l.SelfTest(out)
+
+	expectContainsFalse(t, out.String(), "# ignored:")
+	expectContainsTrue(t, out.String(), "# (no IgnoredRequestURIs configured)\n")
+	expect(t, strings.Count(out.String(), "msg=\"Request received\""), 5)
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// rateLimitHeaders are the response headers logged by LogRateLimitHeaders, along with Retry-After, mapped to their field keys. The X- headers are the de facto standard, the others those of the IETF RateLimit header fields draft, which take precedence.
+var rateLimitHeaders = []struct {
+	names []string
+	key   string
+}{
+	{[]string{"Ratelimit-Limit", "X-Ratelimit-Limit"}, FieldRateLimitLimit},
+	{[]string{"Ratelimit-Remaining", "X-Ratelimit-Remaining"}, FieldRateLimitRemaining},
+	{[]string{"Ratelimit-Reset", "X-Ratelimit-Reset"}, FieldRateLimitReset},
+}
+
+// snapshotRateLimitHeaders registers the response headers logged by LogRateLimitHeaders to be snapshotted.
+func (l *Logger) snapshotRateLimitHeaders() {
+	l.snapshotHeader("Retry-After")
+	for _, h := range rateLimitHeaders {
+		for _, name := range h.names {
+			l.snapshotHeader(name)
+		}
+	}
+}
+
+// rateLimitFields adds the Retry-After and rate limit headers of the response written by crw from start, if any, to fields.
+func (l *Logger) rateLimitFields(fields logrus.Fields, crw *customResponseWriter, start time.Time) {
+	if secs, ok := parseRetryAfter(l.responseHeader(crw, "Retry-After"), start); ok {
+		fields[FieldRetryAfter] = secs
+	}
+	for _, h := range rateLimitHeaders {
+		for _, name := range h.names {
+			if n, ok := parseRateLimitValue(l.responseHeader(crw, name)); ok {
+				fields[h.key] = n
+				break
+			}
+		}
+	}
+}
+
+// parseRateLimitValue parses the leading integer of a rate limit header value, e.g. 100 for "100, 100;w=60".
+func parseRateLimitValue(header string) (int64, bool) {
+	if i := strings.IndexAny(header, ",;"); i >= 0 {
+		header = header[:i]
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(header), 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
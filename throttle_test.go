@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseRateLimitValue(t *testing.T) {
+	for header, want := range map[string]int64{
+		"100":           100,
+		" 42 ":          42,
+		"100, 100;w=60": 100,
+		"10;w=1":        10,
+	} {
+		n, ok := parseRateLimitValue(header)
+		expect(t, ok, true)
+		expect(t, n, want)
+	}
+	for _, header := range []string{"", "-1", "soon"} {
+		_, ok := parseRateLimitValue(header)
+		expect(t, ok, false)
+	}
+}
+
+func TestLogRateLimitHeaders(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:              logger,
+		LogRateLimitHeaders: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "99")
+		w.Header().Set("RateLimit-Remaining", "98")
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_ratelimit_limit=100")
+	expectContainsTrue(t, buf.String(), "http_ratelimit_remaining=98")
+	expectContainsTrue(t, buf.String(), "http_retry_after=30")
+	expectContainsFalse(t, buf.String(), "http_ratelimit_reset")
+
+	// Without LogRateLimitHeaders, Retry-After is only logged for 429 responses.
+	buf.Reset()
+	l = New(Options{Logger: logger})
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	expectContainsFalse(t, buf.String(), "http_ratelimit_limit")
+	expectContainsFalse(t, buf.String(), "http_retry_after")
+}
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TimeoutHandler returns an http.TimeoutHandler running h with the time limit dt, whose timed out requests are logged with `http_timed_out=true` and `http_timeout` by the Logger middleware wrapping it, rather than as ordinary 503 responses.
+func TimeoutHandler(h http.Handler, dt time.Duration, msg string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// ctx expires no later than the context http.TimeoutHandler derives from it with the same limit, and is canceled along with the request.
+		ctx, cancel := context.WithTimeout(r.Context(), dt)
+		defer cancel()
+
+		var mu sync.Mutex
+		var tw http.ResponseWriter
+		th := http.TimeoutHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			tw = w
+			mu.Unlock()
+			h.ServeHTTP(w, r)
+		}), dt, msg)
+		th.ServeHTTP(w, r.WithContext(ctx))
+
+		// Once timed out, the writer given to h fails with ErrHandlerTimeout. It is only unset when h did not even start, because the request either timed out or was canceled by the client.
+		mu.Lock()
+		defer mu.Unlock()
+		if tw == nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				MarkTimedOut(r.Context(), dt)
+			}
+		} else if _, err := tw.Write(nil); err == http.ErrHandlerTimeout {
+			MarkTimedOut(r.Context(), dt)
+		}
+	})
+}
+
+// MarkTimedOut marks the request carried by ctx as timed out after timeout, so that it is logged with `http_timed_out=true` by the Logger middleware. It is meant to be called by timeout middlewares other than TimeoutHandler, once they have responded, and is a no-op when ctx did not come through the middleware.
+func MarkTimedOut(ctx context.Context, timeout time.Duration) {
+	if state, ok := ctx.Value(stateKey).(*requestState); ok {
+		state.timedOut = true
+		state.timeout = timeout
+	}
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestTimeoutHandler(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(TimeoutHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}), time.Millisecond, "timed out")).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusServiceUnavailable)
+	expectContainsTrue(t, buf.String(), "http_status=503")
+	expectContainsTrue(t, buf.String(), "http_timed_out=true")
+	expectContainsTrue(t, buf.String(), "http_timeout=1ms")
+
+	buf.Reset()
+	l.Handler(TimeoutHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}), time.Minute, "timed out")).ServeHTTP(httptest.NewRecorder(), req)
+
+	expectContainsTrue(t, buf.String(), "http_status=503")
+	expectContainsFalse(t, buf.String(), "http_timed_out")
+
+	// Requests canceled by the client are not timed out.
+	buf.Reset()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.Handler(TimeoutHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}), time.Minute, "timed out")).ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+	expectContainsTrue(t, buf.String(), "http_status=503")
+	expectContainsFalse(t, buf.String(), "http_timed_out")
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Maximum number of list members of a W3C tracestate header.
+const maxTraceStateMembers = 32
+
+// traceParent is a parsed W3C traceparent header.
+type traceParent struct {
+	traceID string
+	spanID  string
+	sampled bool
+}
+
+// parseTraceParent parses a W3C traceparent header, e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func parseTraceParent(header string) (traceParent, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || !isLowerHex(parts[0], 2) || parts[0] == "ff" || parts[0] == "00" && len(parts) != 4 {
+		return traceParent{}, false
+	}
+	if !isLowerHex(parts[1], 32) || !isLowerHex(parts[2], 16) || !isLowerHex(parts[3], 2) {
+		return traceParent{}, false
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return traceParent{}, false
+	}
+	return traceParent{
+		traceID: parts[1],
+		spanID:  parts[2],
+		sampled: fromHex(parts[3][1])&1 == 1,
+	}, true
+}
+
+func isLowerHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if (s[i] < '0' || s[i] > '9') && (s[i] < 'a' || s[i] > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func fromHex(c byte) byte {
+	if c >= 'a' {
+		return c - 'a' + 10
+	}
+	return c - '0'
+}
+
+// traceStateValue returns the value of the vendor key carrying the sampled and logged decisions, e.g. "s:1;l:0". logged is decided before the request is served, see Options.TraceStateKey.
+func traceStateValue(sampled, logged bool) string {
+	flag := func(b bool) string {
+		if b {
+			return "1"
+		}
+		return "0"
+	}
+	return "s:" + flag(sampled) + ";l:" + flag(logged)
+}
+
+// mutateTraceState returns the tracestate header with key set to value, moved to the front of the list as the W3C specification requires of updated members.
+func mutateTraceState(header, key, value string) string {
+	members := []string{key + "=" + value}
+	for _, member := range strings.Split(header, ",") {
+		member = strings.TrimSpace(member)
+		if len(member) == 0 || strings.HasPrefix(member, key+"=") {
+			continue
+		}
+		if len(members) == maxTraceStateMembers {
+			break
+		}
+		members = append(members, member)
+	}
+	return strings.Join(members, ",")
+}
+
+// TraceState returns the W3C tracestate header, carrying the Logger's sampled and logged decisions under Options.TraceStateKey, that handlers should propagate on the outbound requests they make on behalf of the request carried by ctx. It returns an empty string when ctx did not come through the middleware, or TraceStateKey is not set.
+func TraceState(ctx context.Context) string {
+	if state, ok := ctx.Value(stateKey).(*requestState); ok {
+		return state.traceState
+	}
+	return ""
+}
+
+// traceContext parses the W3C trace context of r and, when TraceStateKey is set, records the outbound tracestate in state and in the request headers, so handlers forwarding them propagate it.
+func (l *Logger) traceContext(r *http.Request, state *requestState, logged bool) (traceParent, bool) {
+	tp, ok := parseTraceParent(r.Header.Get("Traceparent"))
+	if len(l.opt.TraceStateKey) > 0 && ok {
+		state.traceState = mutateTraceState(r.Header.Get("Tracestate"), l.opt.TraceStateKey, traceStateValue(tp.sampled, logged))
+		r.Header.Set("Tracestate", state.traceState)
+	}
+	return tp, ok
+}
@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceParent(t *testing.T) {
+	tp, ok := parseTraceParent(testTraceParent)
+	expect(t, ok, true)
+	expect(t, tp.traceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+	expect(t, tp.spanID, "00f067aa0ba902b7")
+	expect(t, tp.sampled, true)
+
+	tp, ok = parseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+	expect(t, ok, true)
+	expect(t, tp.sampled, false)
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+	} {
+		if _, ok := parseTraceParent(header); ok {
+			t.Errorf("Expected [%s] to be invalid", header)
+		}
+	}
+}
+
+func TestMutateTraceState(t *testing.T) {
+	expect(t, mutateTraceState("", "acme", "s:1;l:1"), "acme=s:1;l:1")
+	expect(t, mutateTraceState("rojo=00f067aa0ba902b7, acme=s:0;l:0,congo=t61rcWkgMzE", "acme", "s:1;l:0"), "acme=s:1;l:0,rojo=00f067aa0ba902b7,congo=t61rcWkgMzE")
+}
+
+func TestTraceContext(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:       logger,
+		TraceContext: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Traceparent", testTraceParent)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "trace_id=4bf92f3577b34da6a3ce929d0e0e4736")
+	expectContainsTrue(t, buf.String(), "span_id=00f067aa0ba902b7")
+	expectContainsTrue(t, buf.String(), "trace_sampled=true")
+	expect(t, req.Header.Get("Tracestate"), "")
+}
+
+func TestTraceState(t *testing.T) {
+	l := New(Options{
+		Logger:             logrus.New(),
+		TraceStateKey:      "acme",
+		IgnoredRequestURIs: []string{"/ignored"},
+	})
+
+	for uri, expected := range map[string]string{
+		"/foo":     "acme=s:1;l:1,rojo=00f067aa0ba902b7",
+		"/ignored": "acme=s:1;l:0,rojo=00f067aa0ba902b7",
+	} {
+		var propagated string
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", uri, nil)
+		req.RequestURI = uri
+		req.Header.Set("Traceparent", testTraceParent)
+		req.Header.Set("Tracestate", "rojo=00f067aa0ba902b7")
+		l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			propagated = TraceState(r.Context())
+			expect(t, r.Header.Get("Tracestate"), propagated)
+		})).ServeHTTP(res, req)
+
+		expect(t, propagated, expected)
+	}
+}
+
+func TestTraceStateSampled(t *testing.T) {
+	l := New(Options{
+		Logger:            logrus.New(),
+		TraceStateKey:     "acme",
+		SuccessSampleRate: 0.5,
+	})
+
+	for draw, expected := range map[float64]string{
+		0.1: "acme=s:1;l:1",
+		0.9: "acme=s:1;l:0",
+	} {
+		draw := draw
+		l.random = func() float64 { return draw }
+		var propagated string
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		req.Header.Set("Traceparent", testTraceParent)
+		l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			propagated = TraceState(r.Context())
+		})).ServeHTTP(res, req)
+
+		expect(t, propagated, expected)
+	}
+}
+
+func TestTraceStateWithoutTraceParent(t *testing.T) {
+	l := New(Options{
+		Logger:        logrus.New(),
+		TraceStateKey: "acme",
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expect(t, TraceState(r.Context()), "")
+	})).ServeHTTP(res, req)
+}
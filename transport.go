@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Transport is a http.RoundTripper logging the requests it sends with the fields Logger.Handler logs the requests it serves with, so that both sides of a call share one format. The URL sent is logged as `http_uri`, and transport errors as `http_error`. Requests are logged once their response body is read or closed, or as soon as their response is received for upgraded connections, with the size read and the duration since they were sent, so response bodies must be closed, as http.Client requires.
+type Transport struct {
+	// Logger logs the requests, under its current Options: IgnoredRequestURIs and IgnoredMethods, which are matched against the request URI of the URL sent, IgnoredStatusCodes, SuccessSampleRate, ErrorsOnly, LevelByStatus, the redacted headers and query parameters, RequestHeaders, ResponseHeaders, FieldNames and CustomFields.
+	Logger *Logger
+	// Base sends the requests. Default is http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+// Transport returns a Transport logging the requests sent by base with l.
+func (l *Logger) Transport(base http.RoundTripper) *Transport {
+	return &Transport{Logger: l, Base: base}
+}
+
+// RoundTrip sends req with Base, and logs it once its response body is read or closed.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	l := t.Logger.current()
+	if l.ignoredURI(req.URL.RequestURI()) || l.ignoredMethod(req.Method) {
+		return base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	ttfb := time.Since(start)
+	if err != nil {
+		l.roundTripped(req, nil, err, 0, ttfb, ttfb)
+		return resp, err
+	}
+	if l.ignoredStatus(resp.StatusCode) || !l.sampled(resp.StatusCode) || l.opt.ErrorsOnly && resp.StatusCode < 400 {
+		return resp, nil
+	}
+	// The body of a 101 Switching Protocols response is the upgraded connection, an io.ReadWriteCloser left unwrapped for the client to write to.
+	if resp.Body == nil || resp.Body == http.NoBody || resp.StatusCode == http.StatusSwitchingProtocols {
+		l.roundTripped(req, resp, nil, 0, ttfb, ttfb)
+		return resp, nil
+	}
+	resp.Body = &responseBody{ReadCloser: resp.Body, done: func(size int64, err error) {
+		l.roundTripped(req, resp, err, size, ttfb, time.Since(start))
+	}}
+	return resp, nil
+}
+
+// responseBody counts the bytes read from a response body, and calls done once, when it is read to its end, fails or is closed.
+type responseBody struct {
+	io.ReadCloser
+	size int64
+	once sync.Once
+	done func(size int64, err error)
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	switch {
+	case err == io.EOF:
+		b.once.Do(func() { b.done(b.size, nil) })
+	case err != nil:
+		b.once.Do(func() { b.done(b.size, err) })
+	}
+	return n, err
+}
+
+func (b *responseBody) Close() error {
+	b.once.Do(func() { b.done(b.size, nil) })
+	return b.ReadCloser.Close()
+}
+
+// roundTripped logs req, sent in duration, whose response resp, if any, was received after ttfb and size bytes of it read, or which failed with err.
+func (l *Logger) roundTripped(req *http.Request, resp *http.Response, err error, size int64, ttfb, duration time.Duration) {
+	fields := newFields()
+	fields[FieldMethod] = req.Method
+	fields[FieldURI] = redactQuery(redactedURL(req.URL), l.redactedParams)
+	fields[FieldHost] = req.URL.Host
+	fields[FieldScheme] = req.URL.Scheme
+	fields[FieldDuration] = formatDuration(duration, l.opt.DurationFormat)
+	if l.opt.DurationSeconds {
+		fields[FieldDurationSeconds] = duration.Seconds()
+	}
+	for _, h := range l.requestHeaders {
+		if val := headerValue(req.Header, h.name); len(val) > 0 {
+			fields[h.key] = l.redactor.redact(h.name, val)
+		}
+	}
+
+	level, status := logrus.ErrorLevel, 0
+	if resp != nil {
+		status = resp.StatusCode
+		level = l.statusLevel(status)
+		fields[FieldProto] = resp.Proto
+		fields[FieldStatus] = status
+		fields[FieldSize] = size
+		fields[FieldTTFB] = formatDuration(ttfb, l.opt.DurationFormat)
+		for _, h := range l.responseHeaders {
+			if val := headerValue(resp.Header, h.name); len(val) > 0 {
+				fields[h.key] = l.redactor.redact(h.name, val)
+			}
+		}
+	}
+	if err != nil {
+		fields[FieldError] = err.Error()
+		level = logrus.ErrorLevel
+	}
+	if l.opt.OmittedFields != 0 {
+		omitFields(fields, l.opt.OmittedFields)
+	}
+
+	renameFields(fields, l.opt.FieldNames)
+	for key, val := range l.customFields {
+		fields[key] = val
+	}
+	logger := l.opt.Logger
+	if l.opt.ErrorLogger != nil && (err != nil || status >= 400) {
+		logger = l.opt.ErrorLogger
+	}
+	l.emit(logEntry{logger: logger, tee: l.opt.TeeLoggers, time: time.Now(), level: level, message: "Request sent", fields: fields, sync: l.asyncSync(req, status, level), pooled: true})
+}
+
+// redactedURL returns u as a string, with its password, if any, replaced by "xxxxx", as url.URL.Redacted does from Go 1.15 on.
+func redactedURL(u *url.URL) string {
+	if u.User == nil {
+		return u.String()
+	}
+	if _, ok := u.User.Password(); !ok {
+		return u.String()
+	}
+	redacted := *u
+	redacted.User = url.UserPassword(u.User.Username(), "xxxxx")
+	return redacted.String()
+}
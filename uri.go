@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"net/url"
+	"strings"
+)
+
+// newRedactedParams returns the set of lower-cased query parameter names to redact.
+func newRedactedParams(names []string) map[string]bool {
+	if len(names) == 0 {
+		return nil
+	}
+	params := make(map[string]bool, len(names))
+	for _, name := range names {
+		params[strings.ToLower(name)] = true
+	}
+	return params
+}
+
+// redactQuery replaces the values of the query parameters of uri found in params by Redacted, leaving the rest of uri untouched.
+func redactQuery(uri string, params map[string]bool) string {
+	if len(params) == 0 {
+		return uri
+	}
+	q := strings.IndexByte(uri, '?')
+	if q < 0 {
+		return uri
+	}
+	query, fragment := uri[q+1:], ""
+	if f := strings.IndexByte(query, '#'); f >= 0 {
+		query, fragment = query[:f], query[f:]
+	}
+
+	pairs := strings.Split(query, "&")
+	redacted := false
+	for i, pair := range pairs {
+		rawKey := pair
+		if eq := strings.IndexByte(pair, '='); eq >= 0 {
+			rawKey = pair[:eq]
+		}
+		key := rawKey
+		if unescaped, err := url.QueryUnescape(rawKey); err == nil {
+			key = unescaped
+		}
+		if params[strings.ToLower(key)] {
+			pairs[i] = rawKey + "=" + Redacted
+			redacted = true
+		}
+	}
+	if !redacted {
+		return uri
+	}
+	return uri[:q+1] + strings.Join(pairs, "&") + fragment
+}
+
+// URIFields selects how the request URI is logged.
+type URIFields int
+
+const (
+	// URICombined logs the request URI as a whole, as `http_uri`.
+	URICombined URIFields = iota
+	// URISplit logs the path and query of the request URI as `http_path` and `http_query`, instead of `http_uri`.
+	URISplit
+	// URIBoth logs `http_path` and `http_query` in addition to `http_uri`.
+	URIBoth
+)
+
+// splitURI splits uri into its path and query, dropping the fragment if any.
+func splitURI(uri string) (path, query string) {
+	if f := strings.IndexByte(uri, '#'); f >= 0 {
+		uri = uri[:f]
+	}
+	if q := strings.IndexByte(uri, '?'); q >= 0 {
+		return uri[:q], uri[q+1:]
+	}
+	return uri, ""
+}
+
+// CollapseIDs is a PathNormalizer replacing numeric path segments by "{id}" and UUID path segments by "{uuid}", e.g. "/orders/12345/items/" is normalized to "/orders/{id}/items/".
+func CollapseIDs(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		switch {
+		case isNumeric(seg):
+			segments[i] = "{id}"
+		case isUUID(seg):
+			segments[i] = "{uuid}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isNumeric reports whether s is a non-empty run of decimal digits.
+func isNumeric(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isUUID reports whether s is a UUID in its canonical 8-4-4-4-12 hexadecimal form, in any case.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHex(s[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
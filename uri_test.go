@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRedactQuery(t *testing.T) {
+	params := newRedactedParams([]string{"token", "API_KEY"})
+
+	for uri, redacted := range map[string]string{
+		"/foo":                             "/foo",
+		"/foo?":                            "/foo?",
+		"/foo?q=1":                         "/foo?q=1",
+		"/foo?token=abc":                   "/foo?token=REDACTED",
+		"/foo?q=1&Token=abc&token&api_key": "/foo?q=1&Token=REDACTED&token=REDACTED&api_key=REDACTED",
+		"/foo?api%5Fkey=abc#token=x":       "/foo?api%5Fkey=REDACTED#token=x",
+		"/token?tokens=abc":                "/token?tokens=abc",
+	} {
+		expect(t, redactQuery(uri, params), redacted)
+	}
+	expect(t, redactQuery("/foo?token=abc", nil), "/foo?token=abc")
+}
+
+func TestRedactedQueryParams(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:              logger,
+		RedactedQueryParams: []string{"password"},
+	})
+
+	res := httptest.NewRecorder()
+	url := "/login?user=bob&password=secret"
+	req, _ := http.NewRequest("GET", url, nil)
+	req.RequestURI = url
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_uri=\"/login?user=bob&password=REDACTED\"")
+	expectContainsFalse(t, buf.String(), "secret")
+}
+
+func TestSplitURI(t *testing.T) {
+	for uri, expected := range map[string][2]string{
+		"/foo":             {"/foo", ""},
+		"/foo?":            {"/foo", ""},
+		"/foo?q=1&p=2":     {"/foo", "q=1&p=2"},
+		"/foo?q=1#section": {"/foo", "q=1"},
+		"/foo#section?q=1": {"/foo", ""},
+		"*":                {"*", ""},
+	} {
+		path, query := splitURI(uri)
+		expect(t, path, expected[0])
+		expect(t, query, expected[1])
+	}
+}
+
+func TestURIFields(t *testing.T) {
+	url := "/search?q=term&token=secret"
+
+	for fields, expected := range map[URIFields][]string{
+		URICombined: {"http_uri=" + strconv.Quote("/search?q=term&token=REDACTED")},
+		URISplit:    {"http_path=/search", "http_query=" + strconv.Quote("q=term&token=REDACTED")},
+		URIBoth:     {"http_uri=", "http_path=/search", "http_query="},
+	} {
+		buf := bytes.NewBufferString("")
+		logger := logrus.New()
+		logger.SetOutput(buf)
+
+		l := New(Options{
+			Logger:              logger,
+			URIFields:           fields,
+			RedactedQueryParams: []string{"token"},
+		})
+
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", url, nil)
+		req.RequestURI = url
+		l.Handler(myHandler).ServeHTTP(res, req)
+
+		for _, field := range expected {
+			expectContainsTrue(t, buf.String(), field)
+		}
+		if fields == URISplit {
+			expectContainsFalse(t, buf.String(), "http_uri")
+		}
+		if fields == URICombined {
+			expectContainsFalse(t, buf.String(), "http_path")
+		}
+	}
+}
+
+func TestCollapseIDs(t *testing.T) {
+	for path, expected := range map[string]string{
+		"/":                    "/",
+		"/orders/12345":        "/orders/{id}",
+		"/orders/12345/items/": "/orders/{id}/items/",
+		"/users/3F2504E0-4F89-11D3-9A0C-0305E82C3301/avatar": "/users/{uuid}/avatar",
+		"/v2/orders":           "/v2/orders",
+		"/orders/12345abc":     "/orders/12345abc",
+		"/files/3f2504e0-4f89": "/files/3f2504e0-4f89",
+	} {
+		expect(t, CollapseIDs(path), expected)
+	}
+}
+
+func TestPathNormalizer(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:         logger,
+		PathNormalizer: CollapseIDs,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/orders/12345?expand=items", nil)
+	req.RequestURI = "/orders/12345?expand=items"
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_route=\"/orders/{id}\"")
+	expectContainsTrue(t, buf.String(), "http_uri=\"/orders/12345?expand=items\"")
+}
@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ProbeUserAgents is a list of User-Agent prefixes of common health checkers, for use as IgnoredUserAgents: Kubernetes probes, AWS, Google Cloud and Azure load balancers, and Consul.
+var ProbeUserAgents = []string{
+	"kube-probe/",
+	"ELB-HealthChecker/",
+	"GoogleHC/",
+	"Load Balancer Agent",
+	"Consul Health Check",
+}
+
+// UserAgent is the classification of a User-Agent request header.
+type UserAgent struct {
+	// Browser is the browser or client family, e.g. "Chrome" or "curl". Empty when unknown.
+	Browser string
+	// OS is the operating system family, e.g. "Windows" or "iOS". Empty when unknown.
+	OS string
+	// Bot reports whether the client is a crawler, monitor or other automated agent.
+	Bot bool
+}
+
+// UserAgentParser classifies User-Agent request headers, logged as `ua_browser`, `ua_os` and `ua_is_bot`.
+type UserAgentParser interface {
+	// Parse classifies the User-Agent header value ua, which is never empty.
+	Parse(ua string) UserAgent
+}
+
+// SimpleUserAgentParser is a UserAgentParser matching the common browser, operating system and bot tokens. It favours speed over accuracy; plug in a dedicated library for finer classification.
+var SimpleUserAgentParser UserAgentParser = simpleUserAgentParser{}
+
+type simpleUserAgentParser struct{}
+
+// uaToken maps a User-Agent substring to the family it identifies. Tokens are matched in order, so the more specific ones come first, e.g. Edge and Chrome before Safari.
+type uaToken struct {
+	token  string
+	family string
+}
+
+var uaBrowsers = []uaToken{
+	{"Edg/", "Edge"},
+	{"Edge/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Opera", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"MSIE ", "Internet Explorer"},
+	{"Trident/", "Internet Explorer"},
+	{"curl/", "curl"},
+	{"Wget/", "Wget"},
+	{"Go-http-client/", "Go"},
+	{"python-requests/", "Python Requests"},
+}
+
+var uaOSes = []uaToken{
+	{"Windows", "Windows"},
+	{"iPhone", "iOS"},
+	{"iPad", "iOS"},
+	{"Android", "Android"},
+	{"CrOS", "Chrome OS"},
+	{"Mac OS X", "macOS"},
+	{"Macintosh", "macOS"},
+	{"Linux", "Linux"},
+}
+
+var uaBots = []string{"bot", "crawler", "spider", "slurp", "monitor", "headless", "lighthouse", "facebookexternalhit"}
+
+func (simpleUserAgentParser) Parse(ua string) UserAgent {
+	var agent UserAgent
+	for _, t := range uaBrowsers {
+		if strings.Contains(ua, t.token) {
+			agent.Browser = t.family
+			break
+		}
+	}
+	for _, t := range uaOSes {
+		if strings.Contains(ua, t.token) {
+			agent.OS = t.family
+			break
+		}
+	}
+	lower := strings.ToLower(ua)
+	for _, token := range uaBots {
+		if strings.Contains(lower, token) {
+			agent.Bot = true
+			break
+		}
+	}
+	return agent
+}
+
+// userAgentFields adds the classification of the User-Agent request header ua to fields. Unknown browser and OS families are left out.
+func userAgentFields(fields logrus.Fields, parser UserAgentParser, ua string) {
+	agent := parser.Parse(ua)
+	if len(agent.Browser) > 0 {
+		fields[FieldUABrowser] = agent.Browser
+	}
+	if len(agent.OS) > 0 {
+		fields[FieldUAOS] = agent.OS
+	}
+	fields[FieldUAIsBot] = agent.Bot
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSimpleUserAgentParser(t *testing.T) {
+	for ua, want := range map[string]UserAgent{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36":                         {"Chrome", "Windows", false},
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0":           {"Edge", "Windows", false},
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1": {"Safari", "iOS", false},
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36":                   {"Chrome", "Android", false},
+		"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0":                                                                  {"Firefox", "Linux", false},
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)":                                                                {"", "", true},
+		"curl/8.4.0": {"curl", "", false},
+	} {
+		expect(t, SimpleUserAgentParser.Parse(ua), want)
+	}
+}
+
+type fixedUserAgentParser UserAgent
+
+func (p fixedUserAgentParser) Parse(ua string) UserAgent {
+	return UserAgent(p)
+}
+
+func TestUserAgentParser(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:          logger,
+		UserAgentParser: fixedUserAgentParser{Browser: "Crawler", Bot: true},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("User-Agent", "crawler/1.0")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "ua_browser=Crawler")
+	expectContainsTrue(t, buf.String(), "ua_is_bot=true")
+	expectContainsFalse(t, buf.String(), "ua_os=")
+	expectContainsFalse(t, buf.String(), "http_user_agent=")
+
+	buf.Reset()
+	req, _ = http.NewRequest("GET", "/foo", nil)
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), "ua_")
+}
+
+func TestIgnoredUserAgents(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:            logger,
+		IgnoredUserAgents: ProbeUserAgents,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("User-Agent", "kube-probe/1.29")
+	l.Handler(myHandler).ServeHTTP(res, req)
+	req.Header.Set("User-Agent", "ELB-HealthChecker/2.0")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expect(t, buf.String(), "")
+
+	req.Header.Set("User-Agent", "curl/8.4.0 kube-probe/1.29")
+	l.Handler(myHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "http_status=200")
+}
@@ -0,0 +1,11 @@
+//go:build !go1.18
+// +build !go1.18
+
+package logger
+
+import "runtime/debug"
+
+// vcsRevision returns the VCS revision the binary of info was built from. Binaries carry no VCS information before Go 1.18, so it is always empty.
+func vcsRevision(info *debug.BuildInfo) string {
+	return ""
+}
@@ -0,0 +1,16 @@
+//go:build go1.18
+// +build go1.18
+
+package logger
+
+import "runtime/debug"
+
+// vcsRevision returns the VCS revision the binary of info was built from, if known.
+func vcsRevision(info *debug.BuildInfo) string {
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return ""
+}
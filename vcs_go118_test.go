@@ -0,0 +1,21 @@
+//go:build go1.18
+// +build go1.18
+
+package logger
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestBuildInfoFieldsRevision(t *testing.T) {
+	fields := logrus.Fields{}
+	buildInfoFields(fields, &debug.BuildInfo{
+		Main:     debug.Module{Path: "example.com/checkout", Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{{Key: "vcs", Value: "git"}, {Key: "vcs.revision", Value: "0123456789abcdef"}},
+	})
+	expect(t, fields[FieldServiceVersion], "v1.2.3")
+	expect(t, fields[FieldGitSHA], "0123456789abcdef")
+}
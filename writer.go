@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type customResponseWriter struct {
+	http.ResponseWriter
+	start  time.Time
+	status int
+	size   int
+	// ttfb is the time between the start of the request and the final response headers being written.
+	ttfb time.Duration
+	// earlyHints counts the 103 Early Hints responses written, earlyHintsTime is the time between the start of the request and the first of them.
+	earlyHints     int
+	earlyHintsTime time.Duration
+	// informational holds the statuses of the 1xx responses written ahead of the final one.
+	informational []int
+	// body holds up to bodySize bytes of the body of a 5xx response, or of any response when captureBody is set.
+	bodySize    int
+	body        []byte
+	captureBody bool
+	// headerValues holds the values of the headerNames response headers, snapshotted when the final response headers are written, as does header for every response header when snapshotAll is set.
+	headerNames  []string
+	headerValues []string
+	snapshotAll  bool
+	header       http.Header
+	// contentLength is the Content-Length response header, as snapshotted.
+	contentLength string
+	wroteHeader   bool
+	// stream is set for text/event-stream responses, whose flushes are counted. heartbeat, when set, is called on the first flush every heartbeatInterval.
+	stream            bool
+	flushes           int
+	heartbeat         func(c *customResponseWriter)
+	heartbeatInterval time.Duration
+	lastHeartbeat     time.Time
+	// duplicateStatus is the status of the first WriteHeader call made once the final response headers were written, if any.
+	duplicateStatus int
+}
+
+func (c *customResponseWriter) WriteHeader(status int) {
+	if c.wroteHeader {
+		// A superfluous call, which net/http ignores but for a warning.
+		if c.duplicateStatus == 0 {
+			c.duplicateStatus = status
+		}
+		c.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if isInformational(status) {
+		// Informational responses precede the final one, whose status is the one logged.
+		if status == http.StatusEarlyHints {
+			if c.earlyHints == 0 {
+				c.earlyHintsTime = time.Since(c.start)
+			}
+			c.earlyHints++
+		}
+		c.informational = append(c.informational, status)
+		c.ResponseWriter.WriteHeader(status)
+		return
+	}
+	c.snapshotHeaders()
+	c.status = status
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *customResponseWriter) Write(b []byte) (int, error) {
+	// An implicit WriteHeader(http.StatusOK).
+	c.snapshotHeaders()
+	size, err := c.ResponseWriter.Write(b)
+	c.size += size
+	if (c.status >= 500 || c.captureBody) && len(c.body) < c.bodySize {
+		n := c.bodySize - len(c.body)
+		if n > size {
+			n = size
+		}
+		c.body = append(c.body, b[:n]...)
+	}
+	return size, err
+}
+
+// ReadFrom lets io.Copy use the io.ReaderFrom of the wrapped ResponseWriter, so that files are still served with sendfile. Bodies being captured are copied through Write instead.
+func (c *customResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	// An implicit WriteHeader(http.StatusOK).
+	c.snapshotHeaders()
+	rf, ok := c.ResponseWriter.(io.ReaderFrom)
+	if !ok || (c.status >= 500 || c.captureBody) && len(c.body) < c.bodySize {
+		return io.Copy(writerOnly{c}, src)
+	}
+	n, err := rf.ReadFrom(src)
+	c.size += int(n)
+	return n, err
+}
+
+// writerOnly hides the other methods of a Writer, such as ReadFrom, from io.Copy.
+type writerOnly struct {
+	io.Writer
+}
+
+// Unwrap returns the wrapped ResponseWriter, for http.ResponseController to reach the features it implements, such as write deadlines.
+func (c *customResponseWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+func (c *customResponseWriter) Flush() {
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+	if !c.stream {
+		return
+	}
+	c.flushes++
+	if c.heartbeat != nil {
+		if now := time.Now(); now.Sub(c.lastHeartbeat) >= c.heartbeatInterval {
+			c.lastHeartbeat = now
+			c.heartbeat(c)
+		}
+	}
+}
+
+func (c *customResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := c.ResponseWriter.(http.Hijacker); ok {
+		return hj.Hijack()
+	}
+	return nil, nil, fmt.Errorf("ResponseWriter does not implement the Hijacker interface")
+}
+
+// snapshotHeaders records the time to first byte, the Content-Length, whether the response is an event stream, and the values of the logged response headers, the first time it is called.
+func (c *customResponseWriter) snapshotHeaders() {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+	c.ttfb = time.Since(c.start)
+
+	h := c.ResponseWriter.Header()
+	if v := h["Content-Length"]; len(v) > 0 {
+		c.contentLength = v[0]
+	}
+	if v := h["Content-Type"]; len(v) > 0 && strings.HasPrefix(v[0], "text/event-stream") {
+		c.stream = true
+		c.lastHeartbeat = time.Now()
+	}
+	if c.snapshotAll {
+		c.header = h.Clone()
+	}
+	if len(c.headerNames) == 0 {
+		return
+	}
+	c.headerValues = make([]string, len(c.headerNames))
+	for i, name := range c.headerNames {
+		c.headerValues[i] = headerValue(h, name)
+	}
+}
+
+// isInformational reports whether status is a 1xx status sent ahead of the final response. 101 Switching Protocols is final, as no other status follows it.
+func isInformational(status int) bool {
+	return status >= 100 && status < 200 && status != http.StatusSwitchingProtocols
+}
+
+// writerPool recycles the customResponseWriters of completed requests.
+var writerPool = sync.Pool{
+	New: func() interface{} {
+		return new(customResponseWriter)
+	},
+}
+
+func newCustomResponseWriter(w http.ResponseWriter, start time.Time) *customResponseWriter {
+	c := writerPool.Get().(*customResponseWriter)
+	// When WriteHeader is not called, it's safe to assume the status will be 200.
+	*c = customResponseWriter{
+		ResponseWriter: w,
+		start:          start,
+		status:         200,
+	}
+	return c
+}
+
+// releaseCustomResponseWriter returns c to writerPool, once the request it wrapped has been served and logged. Nothing c references is reused, as the logged fields may still hold it.
+func releaseCustomResponseWriter(c *customResponseWriter) {
+	*c = customResponseWriter{}
+	writerPool.Put(c)
+}
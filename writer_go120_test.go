@@ -0,0 +1,37 @@
+//go:build go1.20
+// +build go1.20
+
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// deadlineRecorder is a ResponseRecorder supporting write deadlines, as net/http's response does.
+type deadlineRecorder struct {
+	*httptest.ResponseRecorder
+	deadline time.Time
+}
+
+func (r *deadlineRecorder) SetWriteDeadline(deadline time.Time) error {
+	r.deadline = deadline
+	return nil
+}
+
+func TestResponseController(t *testing.T) {
+	l := New()
+
+	deadline := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	res := &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil {
+			t.Error(err)
+		}
+	})).ServeHTTP(res, req)
+
+	expect(t, res.deadline, deadline)
+}
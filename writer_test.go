@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// readerFromRecorder is a ResponseRecorder implementing io.ReaderFrom, as net/http's response does for sendfile.
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFrom int
+}
+
+func (r *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.readFrom++
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+func TestReadFrom(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:        logger,
+		ErrorBodySize: 16,
+	})
+
+	res := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, struct{ io.Reader }{strings.NewReader("some file contents")})
+	})).ServeHTTP(res, req)
+
+	expect(t, res.readFrom, 1)
+	expect(t, res.Body.String(), "some file contents")
+	expectContainsTrue(t, buf.String(), "http_size=18")
+
+	buf.Reset()
+	res = &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		io.Copy(w, struct{ io.Reader }{strings.NewReader("something went wrong")})
+	})).ServeHTTP(res, req)
+
+	expect(t, res.readFrom, 0)
+	expectContainsTrue(t, buf.String(), "http_size=20")
+	expectContainsTrue(t, buf.String(), `http_error_body="something went w"`)
+}
+
+func TestEventStream(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	logger := logrus.New()
+	logger.SetOutput(buf)
+
+	l := New(Options{
+		Logger:          logger,
+		StreamHeartbeat: time.Nanosecond,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events", nil)
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 3; i++ {
+			w.Write([]byte("data: ping\n\n"))
+			time.Sleep(time.Millisecond)
+			w.(http.Flusher).Flush()
+		}
+	})).ServeHTTP(res, req)
+
+	expect(t, strings.Count(buf.String(), `msg="Stream in progress"`), 3)
+	expectContainsTrue(t, buf.String(), "http_stream_flushes=3")
+	expectContainsTrue(t, buf.String(), "http_size=36")
+	expect(t, strings.Count(buf.String(), "http_stream=true"), 4)
+
+	buf.Reset()
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("bar"))
+		w.(http.Flusher).Flush()
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	expectContainsFalse(t, buf.String(), "http_stream")
+}